@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// batchCmd represents the batch command
+var (
+	// Flags
+	batchTotalAliens uint
+	batchMaxSteps    uint
+	batchMapFilepath string
+	batchMapFormat   string
+	batchSeed        int64
+	batchRuns        uint
+	batchWorkers     int
+	batchFormat      string
+	batchStepMode    string
+	batchMovement    string
+	batchStayProb    float64
+	batchLinkCheck   string
+	batchTwoWay      bool
+
+	// Commands
+	batchCmd = &cobra.Command{
+		Use:   "batch",
+		Short: "Run a Monte Carlo batch of simulations",
+		Long: `Run the same world map several times with different seeds and report aggregated statistics:
+distribution of steps to termination, probability each city survives, mean number of destroyed cities and confidence intervals.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := os.Open(batchMapFilepath)
+			defer func() { _ = in.Close() }()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("seed") {
+				batchSeed = time.Now().UnixNano()
+			}
+			c := &batchConfig{
+				totalAliens: batchTotalAliens,
+				maxSteps:    batchMaxSteps,
+				seed:        batchSeed,
+				runs:        batchRuns,
+				workers:     batchWorkers,
+				format:      batchFormat,
+				stepMode:    batchStepMode,
+				movement:    batchMovement,
+				stayProb:    batchStayProb,
+				linkCheck:   batchLinkCheck,
+				twoWay:      batchTwoWay,
+				in:          in,
+				inputName:   batchMapFilepath,
+				mapFormat:   batchMapFormat,
+				out:         cmd.OutOrStdout(),
+			}
+			return runBatch(cmd.Context(), c)
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	batchCmd.Flags().UintVarP(&batchTotalAliens, "aliens", "n", 5, "total number of aliens")
+	batchCmd.Flags().UintVarP(&batchMaxSteps, "steps", "s", 10000, "maximum number of steps")
+	batchCmd.Flags().StringVarP(&batchMapFilepath, "file", "m", "map.txt", "world map file path")
+	batchCmd.Flags().StringVarP(&batchMapFormat, "map-format", "M", "", "world map format (text, json, yaml), detected from the file extension by default")
+	batchCmd.Flags().Int64VarP(&batchSeed, "seed", "r", 0, "random generator seed of the first run (defaults to a time based seed)")
+	batchCmd.Flags().UintVarP(&batchRuns, "runs", "N", 100, "total number of runs")
+	batchCmd.Flags().IntVarP(&batchWorkers, "workers", "w", runtime.NumCPU(), "number of runs executed in parallel")
+	batchCmd.Flags().StringVar(&batchFormat, "format", outputFormatText, "statistics format (text, json)")
+	batchCmd.Flags().StringVarP(&batchStepMode, "step-mode", "t", simulator.Sequential.String(), "step semantics (sequential, simultaneous)")
+	batchCmd.Flags().StringVarP(&batchMovement, "movement", "b", simulator.UniformMovementName, "alien movement strategy (uniform, stay, unvisited, lazy, seek)")
+	batchCmd.Flags().Float64VarP(&batchStayProb, "stay-probability", "q", 0.5, "probability that an alien stays in place with the stay movement strategy")
+	batchCmd.Flags().StringVarP(&batchLinkCheck, "link-check", "l", simulator.LinkCheckOff.String(), "what is done with geometrically inconsistent links (off, warn, reject, repair)")
+
+	batchCmd.Flags().BoolVarP(&batchTwoWay, "two-way", "u", false, "treat every link as a two-way road and add its reverse link")
+
+	rootCmd.AddCommand(batchCmd)
+}
+
+type batchConfig struct {
+	totalAliens, maxSteps, runs uint
+	seed                        int64
+	workers                     int
+	format, stepMode, movement  string
+	linkCheck                   string
+	twoWay                      bool
+	inputName, mapFormat        string
+	stayProb                    float64
+	in                          io.Reader
+	out                         io.Writer
+}
+
+func runBatch(ctx context.Context, c *batchConfig) error {
+	if c.format != outputFormatText && c.format != reportFormatJSON {
+		return entity.ErrUnknownOutputFormat
+	}
+	stepMode := simulator.Sequential
+	if c.stepMode != "" {
+		var err error
+		stepMode, err = simulator.ParseStepMode(c.stepMode)
+		if err != nil {
+			return err
+		}
+	}
+	movement, err := simulator.NewMovementStrategy(c.movement, c.stayProb)
+	if err != nil {
+		return err
+	}
+	linkCheckMode := simulator.LinkCheckOff
+	if c.linkCheck != "" {
+		linkCheckMode, err = simulator.ParseLinkCheckMode(c.linkCheck)
+		if err != nil {
+			return err
+		}
+	}
+	mapReader, err := simulator.NewMapReader(c.mapFormat, c.inputName)
+	if err != nil {
+		return err
+	}
+	input, err := io.ReadAll(c.in)
+	if err != nil {
+		return err
+	}
+
+	// Run batch
+	runner, err := simulator.NewBatchRunner(input, c.totalAliens, c.maxSteps, c.runs, c.seed, c.workers)
+	if err != nil {
+		return err
+	}
+	runner.SetMapReader(mapReader)
+	runner.SetStepMode(stepMode)
+	runner.SetMovementStrategy(movement)
+	runner.SetTwoWayLinks(c.twoWay)
+	runner.SetLinkCheckMode(linkCheckMode)
+	stats, err := runner.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Write statistics
+	switch c.format {
+	case reportFormatJSON:
+		return stats.WriteJSON(c.out)
+	default:
+		_, err = fmt.Fprintf(c.out, "Aliens: %d, maximum steps: %d, step mode: %s, movement: %s\n", c.totalAliens, c.maxSteps, stepMode, movement)
+		if err != nil {
+			return err
+		}
+		return stats.WriteText(c.out)
+	}
+}
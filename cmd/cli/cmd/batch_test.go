@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runBatch(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name, giveFormat, giveStepMode, giveMovement, wantContains string
+		wantError                                                  error
+	}{
+		{
+			name:         "Case 1: text format",
+			giveFormat:   outputFormatText,
+			wantContains: "City survival probability:",
+			wantError:    nil,
+		},
+		{
+			name:         "Case 2: JSON format",
+			giveFormat:   reportFormatJSON,
+			wantContains: `"city_survivals"`,
+			wantError:    nil,
+		},
+		{
+			name:       "Case 3: unknown format",
+			giveFormat: "xml",
+			wantError:  entity.ErrUnknownOutputFormat,
+		},
+		{
+			name:         "Case 4: simultaneous step mode",
+			giveFormat:   outputFormatText,
+			giveStepMode: simulator.Simultaneous.String(),
+			wantContains: "step mode: simultaneous",
+			wantError:    nil,
+		},
+		{
+			name:         "Case 5: unknown step mode",
+			giveFormat:   outputFormatText,
+			giveStepMode: "random",
+			wantError:    entity.ErrUnknownStepMode,
+		},
+		{
+			name:         "Case 6: lazy movement",
+			giveFormat:   outputFormatText,
+			giveMovement: simulator.LazyMovementName,
+			wantContains: "movement: lazy",
+			wantError:    nil,
+		},
+		{
+			name:         "Case 7: unknown movement",
+			giveFormat:   outputFormatText,
+			giveMovement: "teleport",
+			wantError:    entity.ErrUnknownMovementStrategy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			c := &batchConfig{
+				totalAliens: 2,
+				maxSteps:    100,
+				runs:        20,
+				seed:        42,
+				workers:     4,
+				format:      tt.giveFormat,
+				stepMode:    tt.giveStepMode,
+				movement:    tt.giveMovement,
+				in:          strings.NewReader(input),
+				out:         out,
+			}
+			err := runBatch(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+			require.Contains(t, out.String(), tt.wantContains)
+			if tt.giveFormat == reportFormatJSON {
+				stats := make(map[string]interface{})
+				require.NoError(t, json.Unmarshal(out.Bytes(), &stats))
+				require.Equal(t, 20.0, stats["runs"])
+			}
+		})
+	}
+
+	t.Run("Case 8: no run", func(t *testing.T) {
+		c := &batchConfig{
+			totalAliens: 2,
+			maxSteps:    100,
+			runs:        0,
+			seed:        42,
+			workers:     4,
+			format:      outputFormatText,
+			in:          strings.NewReader(input),
+			out:         &bytes.Buffer{},
+		}
+		err := runBatch(context.Background(), c)
+		require.ErrorIs(t, err, entity.ErrInvalidRunCount)
+	})
+}
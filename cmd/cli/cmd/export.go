@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+)
+
+// exportCmd represents the export command
+var (
+	// Flags
+	exportMapFilepath string
+	exportMapFormat   string
+	exportTwoWay      bool
+	exportDotFile     string
+
+	// Commands
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export a world map",
+	}
+	exportDotCmd = &cobra.Command{
+		Use:   "dot",
+		Short: "Export a world map as a Graphviz DOT graph",
+		Long: `Export the city graph of a world map in the Graphviz DOT language, with the links labelled by their direction.
+The graph can be drawn with Graphviz, e.g. dot -Tsvg map.dot -o map.svg`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := os.Open(exportMapFilepath)
+			defer func() { _ = in.Close() }()
+			if err != nil {
+				return err
+			}
+			c := &exportConfig{
+				in:        in,
+				inputName: exportMapFilepath,
+				mapFormat: exportMapFormat,
+				twoWay:    exportTwoWay,
+				out:       cmd.OutOrStdout(),
+			}
+			if exportDotFile != "" {
+				out, err := os.Create(exportDotFile)
+				if err != nil {
+					return err
+				}
+				defer func() { _ = out.Close() }()
+				c.out = out
+			}
+			return runExportDot(cmd.Context(), c)
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	exportDotCmd.Flags().StringVarP(&exportMapFilepath, "file", "m", "map.txt", "world map file path")
+	exportDotCmd.Flags().StringVarP(&exportMapFormat, "map-format", "M", "", "world map format (text, json, yaml), detected from the file extension by default")
+	exportDotCmd.Flags().BoolVarP(&exportTwoWay, "two-way", "u", false, "treat every link as a two-way road and add its reverse link")
+	exportDotCmd.Flags().StringVarP(&exportDotFile, "dot", "d", "", "file path where the DOT graph is written (defaults to the output)")
+
+	exportCmd.AddCommand(exportDotCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+type exportConfig struct {
+	in        io.Reader
+	inputName string
+	mapFormat string
+	twoWay    bool
+	out       io.Writer
+}
+
+// loadExportWorld reads the world map to export and loads it to a new world
+func loadExportWorld(ctx context.Context, c *exportConfig) (*simulator.World, error) {
+	mapReader, err := simulator.NewMapReader(c.mapFormat, c.inputName)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := mapReader.Read(c.in)
+	if err != nil {
+		return nil, err
+	}
+	world := simulator.NewWorld()
+	err = definition.LoadToWorld(ctx, world)
+	if err != nil {
+		return nil, err
+	}
+	if c.twoWay {
+		err = definition.AddReverseLinks(ctx, world)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return world, nil
+}
+
+// runExportDot exports the world map as a DOT graph
+func runExportDot(ctx context.Context, c *exportConfig) error {
+	world, err := loadExportWorld(ctx, c)
+	if err != nil {
+		return err
+	}
+	return simulator.NewDotWriter().Write(ctx, world, c.out)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_runExportDot(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		giveTwoWay bool
+		wantOut    string
+		wantError  error
+	}{
+		{
+			name:  "Case 1: one-way map",
+			input: "City1 north=City2\nCity2 west=City3\n",
+			wantOut: `digraph world {
+  node [shape=box];
+  "City1" [label="City1"];
+  "City2" [label="City2"];
+  "City3" [label="City3"];
+  "City1" -> "City2" [label=north];
+  "City2" -> "City3" [label=west];
+}
+`,
+		},
+		{
+			name:       "Case 2: two-way map",
+			input:      "City1 north=City2\n",
+			giveTwoWay: true,
+			wantOut: `digraph world {
+  node [shape=box];
+  "City1" [label="City1"];
+  "City2" [label="City2"];
+  "City1" -> "City2" [label=north];
+  "City2" -> "City1" [label=south];
+}
+`,
+		},
+		{
+			name:      "Case 3: invalid map",
+			input:     "City1 up=City2\n",
+			wantError: entity.ErrUnknownDirection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			c := &exportConfig{
+				in:        strings.NewReader(tt.input),
+				inputName: "map.txt",
+				twoWay:    tt.giveTwoWay,
+				out:       out,
+			}
+			err := runExportDot(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+)
+
+// generateCmd represents the generate command
+var (
+	// Flags
+	generateShape     string
+	generateWidth     uint
+	generateHeight    uint
+	generateDensity   float64
+	generateIslands   uint
+	generateSeed      int64
+	generateOutput    string
+	generateMapFormat string
+
+	// Commands
+	generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a random world map",
+		Long: `Generate a random world map, with the cities laid out on a grid and linked with two-way roads to their nearest neighbours:
+grid: rectangular grid where every city is linked to its neighbours
+sparse-grid: rectangular grid where the roads are kept given the density
+random: random planar-ish graph where the grid positions hold a city given the density
+islands: disconnected random planar-ish graphs`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("seed") {
+				generateSeed = time.Now().UnixNano()
+			}
+			c := &generateConfig{
+				shape:      generateShape,
+				width:      generateWidth,
+				height:     generateHeight,
+				density:    generateDensity,
+				islands:    generateIslands,
+				seed:       generateSeed,
+				outputName: generateOutput,
+				mapFormat:  generateMapFormat,
+				out:        cmd.OutOrStdout(),
+				errOut:     cmd.ErrOrStderr(),
+			}
+			if generateOutput != "" {
+				out, err := os.Create(generateOutput)
+				if err != nil {
+					return err
+				}
+				defer func() { _ = out.Close() }()
+				c.out = out
+			}
+			return runGenerate(cmd.Context(), c)
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	generateCmd.Flags().StringVar(&generateShape, "shape", simulator.GridShape.String(), "map shape (grid, sparse-grid, random, islands)")
+	generateCmd.Flags().UintVarP(&generateWidth, "width", "x", 10, "number of columns of the grid (of each island with the islands shape)")
+	generateCmd.Flags().UintVarP(&generateHeight, "height", "y", 10, "number of rows of the grid (of each island with the islands shape)")
+	generateCmd.Flags().Float64VarP(&generateDensity, "density", "D", 0.7, "probability that a road is kept (sparse-grid), or that a grid position holds a city (random, islands)")
+	generateCmd.Flags().UintVar(&generateIslands, "islands", 3, "number of islands with the islands shape")
+	generateCmd.Flags().Int64VarP(&generateSeed, "seed", "r", 0, "random generator seed (defaults to a time based seed)")
+	generateCmd.Flags().StringVar(&generateOutput, "output", "", "file path where the generated map is written (defaults to the output)")
+	generateCmd.Flags().StringVarP(&generateMapFormat, "map-format", "M", "", "generated map format (text, json, yaml), detected from the output file extension by default")
+
+	rootCmd.AddCommand(generateCmd)
+}
+
+type generateConfig struct {
+	shape         string
+	width, height uint
+	density       float64
+	islands       uint
+	seed          int64
+	outputName    string
+	mapFormat     string
+	out, errOut   io.Writer
+}
+
+// runGenerate generates a random world map and writes it
+func runGenerate(ctx context.Context, c *generateConfig) error {
+	shape, err := simulator.ParseMapShape(c.shape)
+	if err != nil {
+		return err
+	}
+	generator, err := simulator.NewMapGenerator(shape, c.width, c.height, simulator.NewRandomSeeded(c.seed))
+	if err != nil {
+		return err
+	}
+	err = generator.SetDensity(c.density)
+	if err != nil {
+		return err
+	}
+	if shape == simulator.IslandsShape {
+		err = generator.SetIslands(c.islands)
+		if err != nil {
+			return err
+		}
+	}
+	mapWriter, err := simulator.NewMapWriter(c.mapFormat, c.outputName)
+	if err != nil {
+		return err
+	}
+
+	// Echo seed so that the map can be generated again
+	_, err = fmt.Fprintf(c.errOut, "Seed: %d\n", c.seed)
+	if err != nil {
+		return err
+	}
+
+	world := simulator.NewWorld()
+	err = generator.Generate(ctx, world)
+	if err != nil {
+		return err
+	}
+	return mapWriter.Write(ctx, world, c.out)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_runGenerate(t *testing.T) {
+	tests := []struct {
+		name            string
+		giveShape       string
+		giveDensity     float64
+		giveIslands     uint
+		giveMapFormat   string
+		wantTotalCities int
+		wantError       error
+	}{
+		{
+			name:            "Case 1: grid",
+			giveShape:       "grid",
+			giveDensity:     0.7,
+			wantTotalCities: 12,
+		},
+		{
+			name:            "Case 2: sparse grid as YAML",
+			giveShape:       "sparse-grid",
+			giveDensity:     0.5,
+			giveMapFormat:   "yaml",
+			wantTotalCities: 12,
+		},
+		{
+			name:            "Case 3: islands",
+			giveShape:       "islands",
+			giveDensity:     1,
+			giveIslands:     2,
+			wantTotalCities: 24,
+		},
+		{
+			name:        "Case 4: unknown shape",
+			giveShape:   "torus",
+			giveDensity: 0.7,
+			wantError:   entity.ErrUnknownMapShape,
+		},
+		{
+			name:        "Case 5: invalid density",
+			giveShape:   "random",
+			giveDensity: 2,
+			wantError:   entity.ErrInvalidProbability,
+		},
+		{
+			name:        "Case 6: no island",
+			giveShape:   "islands",
+			giveDensity: 0.7,
+			giveIslands: 0,
+			wantError:   entity.ErrInvalidMapSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			errOut := &bytes.Buffer{}
+			c := &generateConfig{
+				shape:     tt.giveShape,
+				width:     4,
+				height:    3,
+				density:   tt.giveDensity,
+				islands:   tt.giveIslands,
+				seed:      42,
+				mapFormat: tt.giveMapFormat,
+				out:       out,
+				errOut:    errOut,
+			}
+			err := runGenerate(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+			if tt.wantError != nil {
+				return
+			}
+			require.Equal(t, "Seed: 42\n", errOut.String())
+
+			// The generated map is valid
+			mapReader, err := simulator.NewMapReader(tt.giveMapFormat, "")
+			require.NoError(t, err)
+			definition, err := mapReader.Read(strings.NewReader(out.String()))
+			require.NoError(t, err)
+			require.Len(t, definition.CityNames(), tt.wantTotalCities)
+		})
+	}
+}
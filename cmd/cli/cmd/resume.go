@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+)
+
+// resumeCmd represents the resume command
+var (
+	// Flags
+	resumeCheckpointFile     string
+	resumeCheckpointInterval uint
+	resumeMaxSteps           uint
+	resumeOutputFormat       string
+	resumeEventsFile         string
+	resumeReportFormat       string
+	resumeReportFile         string
+
+	// Commands
+	resumeCmd = &cobra.Command{
+		Use:   "resume",
+		Short: "Resume a simulation from a checkpoint",
+		Long: `Resume a simulation from a checkpoint written with the checkpoint-file flag.
+The resumed simulation produces the same result as an uninterrupted run, and keeps on writing its checkpoints to the same file.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checkpoint, err := readCheckpointFile(resumeCheckpointFile)
+			if err != nil {
+				return err
+			}
+			c := &config{
+				maxSteps:           checkpoint.MaxSteps,
+				seed:               checkpoint.Random.Seed,
+				outputFormat:       resumeOutputFormat,
+				reportFormat:       resumeReportFormat,
+				out:                cmd.OutOrStdout(),
+				errOut:             cmd.ErrOrStderr(),
+				eventsFile:         resumeEventsFile,
+				reportFile:         resumeReportFile,
+				checkpointFile:     resumeCheckpointFile,
+				checkpointInterval: resumeCheckpointInterval,
+				checkpoint:         checkpoint,
+			}
+			if cmd.Flags().Changed("steps") {
+				c.maxSteps = resumeMaxSteps
+			}
+			return runCommand(cmd.Context(), c)
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	resumeCmd.Flags().StringVarP(&resumeCheckpointFile, "checkpoint-file", "c", "checkpoint.json", "checkpoint file path")
+	resumeCmd.Flags().UintVarP(&resumeCheckpointInterval, "checkpoint-interval", "k", 0, "number of steps between two checkpoints (0 to checkpoint only when the simulation ends)")
+	resumeCmd.Flags().UintVarP(&resumeMaxSteps, "steps", "s", 0, "maximum number of steps (defaults to the one of the checkpoint)")
+	resumeCmd.Flags().StringVarP(&resumeOutputFormat, "output-format", "o", outputFormatText, "output format (text, jsonl)")
+	resumeCmd.Flags().StringVarP(&resumeEventsFile, "events-file", "e", "", "file path where the simulation events are written as JSON Lines")
+	resumeCmd.Flags().StringVarP(&resumeReportFormat, "report-format", "f", "", "end of run report format (json, yaml)")
+	resumeCmd.Flags().StringVarP(&resumeReportFile, "report-file", "p", "", "file path where the end of run report is written (defaults to the output)")
+
+	rootCmd.AddCommand(resumeCmd)
+}
+
+// readCheckpointFile reads a checkpoint from a file
+func readCheckpointFile(path string) (*simulator.Checkpoint, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = in.Close() }()
+	return simulator.ReadCheckpoint(in)
+}
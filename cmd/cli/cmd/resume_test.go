@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runSimulator_Resume(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1 east=City4
+City3 west=City1 north=City4
+City4 west=City2 south=City3 east=City5
+City5 west=City4
+`
+
+	ctx := context.Background()
+
+	// Uninterrupted run
+	out := &bytes.Buffer{}
+	c := &config{
+		totalAliens:  3,
+		maxSteps:     50,
+		seed:         42,
+		reportFormat: reportFormatJSON,
+		in:           io.NopCloser(strings.NewReader(input)),
+		out:          &bytes.Buffer{},
+		errOut:       &bytes.Buffer{},
+		reportOut:    out,
+	}
+	wantOutcome, err := runSimulator(ctx, c)
+	require.NoError(t, err)
+	wantReport := out.String()
+
+	// Interrupted run
+	checkpointFilepath := filepath.Join(t.TempDir(), "checkpoint.json")
+	c = &config{
+		totalAliens:    3,
+		maxSteps:       5,
+		seed:           42,
+		in:             io.NopCloser(strings.NewReader(input)),
+		out:            &bytes.Buffer{},
+		errOut:         &bytes.Buffer{},
+		checkpointFile: checkpointFilepath,
+	}
+	_, err = runSimulator(ctx, c)
+	require.NoError(t, err)
+
+	// Resumed run
+	checkpoint, err := readCheckpointFile(checkpointFilepath)
+	require.NoError(t, err)
+	require.Equal(t, uint(5), checkpoint.Step)
+	out = &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	c = &config{
+		maxSteps:       50,
+		seed:           checkpoint.Random.Seed,
+		reportFormat:   reportFormatJSON,
+		out:            &bytes.Buffer{},
+		errOut:         errOut,
+		reportOut:      out,
+		checkpointFile: checkpointFilepath,
+		checkpoint:     checkpoint,
+	}
+	gotOutcome, err := runSimulator(ctx, c)
+	require.NoError(t, err)
+	require.Equal(t, wantOutcome, gotOutcome)
+	require.Equal(t, wantReport, out.String())
+	require.Contains(t, errOut.String(), "Resumed at step 5\n")
+
+	// Checkpoint is updated when the resumed run ends
+	checkpoint, err = readCheckpointFile(checkpointFilepath)
+	require.NoError(t, err)
+	require.Equal(t, wantOutcome.Steps, checkpoint.Step)
+}
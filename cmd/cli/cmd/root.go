@@ -2,21 +2,49 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
 )
 
 // rootCmd represents the base command when called without any subcommands
 var (
 	// Flags
-	totalAliens uint
-	maxSteps    uint
-	mapFilepath string
+	totalAliens        uint
+	maxSteps           uint
+	mapFilepath        string
+	mapFormat          string
+	seed               int64
+	outputFormat       string
+	eventsFile         string
+	reportFormat       string
+	reportFile         string
+	stepMode           string
+	movement           string
+	stayProb           float64
+	checkpointFile     string
+	checkpointInterval uint
+	linkCheck          string
+	twoWay             bool
+	finalMapFile       string
+	dotFile            string
+	render             string
+	pngFile            string
+	gifFile            string
+	gifInterval        uint
+
+	// Exit code of the command when the simulation succeeds
+	exitCode int
 
 	// Commands
 	rootCmd = &cobra.Command{
@@ -30,22 +58,52 @@ More informations available at: https://github.com/jpraynaud/alien-invasion-simu
 			if err != nil {
 				return err
 			}
+			if !cmd.Flags().Changed("seed") {
+				seed = time.Now().UnixNano()
+			}
 			c := &config{
-				totalAliens: totalAliens,
-				maxSteps:    maxSteps,
-				in:          in,
-				out:         cmd.OutOrStdout(),
+				totalAliens:        totalAliens,
+				maxSteps:           maxSteps,
+				seed:               seed,
+				outputFormat:       outputFormat,
+				reportFormat:       reportFormat,
+				stepMode:           stepMode,
+				movement:           movement,
+				stayProb:           stayProb,
+				in:                 in,
+				inputName:          mapFilepath,
+				mapFormat:          mapFormat,
+				out:                cmd.OutOrStdout(),
+				errOut:             cmd.ErrOrStderr(),
+				eventsFile:         eventsFile,
+				reportFile:         reportFile,
+				checkpointFile:     checkpointFile,
+				checkpointInterval: checkpointInterval,
+				linkCheck:          linkCheck,
+				twoWay:             twoWay,
+				finalMapFile:       finalMapFile,
+				dotFile:            dotFile,
+				render:             render,
+				pngFile:            pngFile,
+				gifFile:            gifFile,
+				gifInterval:        gifInterval,
 			}
-			return runSimulator(cmd.Context(), c)
+			return runCommand(cmd.Context(), c)
 		},
 	}
 )
 
 // Execute executes the command
+// The exit code reflects the termination reason of the simulation
+// SIGINT and SIGTERM signals cancel the simulation, which still reports its partial state
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(exitCodeError)
 	}
+	os.Exit(exitCode)
 }
 
 func init() {
@@ -53,41 +111,340 @@ func init() {
 	rootCmd.Flags().UintVarP(&totalAliens, "aliens", "n", 5, "total number of aliens")
 	rootCmd.Flags().UintVarP(&maxSteps, "steps", "s", 10000, "maximum number of steps")
 	rootCmd.Flags().StringVarP(&mapFilepath, "file", "m", "map.txt", "world map file path")
+	rootCmd.Flags().StringVarP(&mapFormat, "map-format", "M", "", "world map format (text, json, yaml), detected from the file extension by default")
+	rootCmd.Flags().Int64VarP(&seed, "seed", "r", 0, "random generator seed (defaults to a time based seed)")
+	rootCmd.Flags().StringVarP(&outputFormat, "output-format", "o", outputFormatText, "output format (text, jsonl)")
+	rootCmd.Flags().StringVarP(&eventsFile, "events-file", "e", "", "file path where the simulation events are written as JSON Lines")
+	rootCmd.Flags().StringVarP(&reportFormat, "report-format", "f", "", "end of run report format (json, yaml)")
+	rootCmd.Flags().StringVarP(&reportFile, "report-file", "p", "", "file path where the end of run report is written (defaults to the output)")
+	rootCmd.Flags().StringVarP(&stepMode, "step-mode", "t", simulator.Sequential.String(), "step semantics (sequential, simultaneous)")
+	rootCmd.Flags().StringVarP(&movement, "movement", "b", simulator.UniformMovementName, "alien movement strategy (uniform, stay, unvisited, lazy, seek)")
+	rootCmd.Flags().Float64VarP(&stayProb, "stay-probability", "q", 0.5, "probability that an alien stays in place with the stay movement strategy")
+	rootCmd.Flags().StringVarP(&checkpointFile, "checkpoint-file", "c", "", "file path where a checkpoint of the simulation is written when it ends")
+	rootCmd.Flags().UintVarP(&checkpointInterval, "checkpoint-interval", "k", 0, "number of steps between two checkpoints (0 to checkpoint only when the simulation ends)")
+	rootCmd.Flags().StringVarP(&linkCheck, "link-check", "l", simulator.LinkCheckOff.String(), "what is done with geometrically inconsistent links (off, warn, reject, repair)")
+	rootCmd.Flags().BoolVarP(&twoWay, "two-way", "u", false, "treat every link as a two-way road and add its reverse link")
+	rootCmd.Flags().StringVar(&finalMapFile, "final-map", "", "file path where the world map of the surviving cities is written, in the format of its extension")
+	rootCmd.Flags().StringVarP(&dotFile, "dot", "d", "", "file path where the city graph of the world is written in the Graphviz DOT language when the simulation ends")
+	rootCmd.Flags().StringVar(&render, "render", "", "render the world as an ASCII grid on the output, when the simulation ends or after every step (final, step)")
+	rootCmd.Flags().StringVar(&pngFile, "png", "", "file path where the world is rendered as a PNG image when the simulation ends")
+	rootCmd.Flags().StringVar(&gifFile, "gif", "", "file path where the world is rendered as an animated GIF image across the steps")
+	rootCmd.Flags().UintVar(&gifInterval, "gif-interval", 1, "number of steps between two frames of the animated GIF image (0 for the first and final frames only)")
 }
 
 type dependencies struct {
 	simulator simulator.Simulator
 	world     simulator.WorldStorer
 	random    simulator.Randomer
+	report    *simulator.ReportObserver
 }
 
 type config struct {
 	totalAliens, maxSteps uint
+	seed                  int64
+	outputFormat          string
+	reportFormat          string
+	stepMode              string
+	movement              string
+	stayProb              float64
 	in                    io.ReadCloser
-	out                   io.Writer
+	inputName             string
+	mapFormat             string
+	out, errOut           io.Writer
+	eventsOut, reportOut  io.Writer
+	dotOut                io.Writer
+	pngOut, gifOut        io.Writer
+	eventsFile            string
+	reportFile            string
+	checkpointFile        string
+	checkpointInterval    uint
+	checkpoint            *simulator.Checkpoint
+	linkCheck             string
+	twoWay                bool
+	finalMapFile          string
+	dotFile               string
+	render                string
+	pngFile               string
+	gifFile               string
+	gifInterval           uint
+}
+
+const (
+	// Human readable output format
+	outputFormatText = "text"
+
+	// JSON Lines events output format
+	outputFormatJSONL = "jsonl"
+
+	// JSON report format
+	reportFormatJSON = "json"
+
+	// YAML report format
+	reportFormatYAML = "yaml"
+)
+
+const (
+	// Exit code when all the aliens are trapped or all the cities are destroyed
+	exitCodeSuccess = 0
+
+	// Exit code when an error occurred
+	exitCodeError = 1
+
+	// Exit code when the maximum number of steps was reached
+	exitCodeMaxStepsReached = 2
+
+	// Exit code when the simulation was cancelled
+	exitCodeCancelled = 3
+)
+
+func exitCodeForOutcome(outcome *simulator.Outcome) int {
+	switch outcome.Reason {
+	case simulator.AllAliensTrapped, simulator.AllCitiesDestroyed:
+		return exitCodeSuccess
+	case simulator.MaxStepsReached:
+		return exitCodeMaxStepsReached
+	case simulator.Cancelled:
+		return exitCodeCancelled
+	default:
+		return exitCodeError
+	}
+}
+
+// runCommand opens the output files, runs the simulator and sets the exit code
+func runCommand(ctx context.Context, c *config) error {
+	if c.eventsFile != "" {
+		eventsOut, err := os.Create(c.eventsFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = eventsOut.Close() }()
+		c.eventsOut = eventsOut
+	}
+	if c.reportFile != "" {
+		reportOut, err := os.Create(c.reportFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = reportOut.Close() }()
+		c.reportOut = reportOut
+	}
+	if c.dotFile != "" {
+		dotOut, err := os.Create(c.dotFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = dotOut.Close() }()
+		c.dotOut = dotOut
+	}
+	if c.pngFile != "" {
+		pngOut, err := os.Create(c.pngFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = pngOut.Close() }()
+		c.pngOut = pngOut
+	}
+	if c.gifFile != "" {
+		gifOut, err := os.Create(c.gifFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gifOut.Close() }()
+		c.gifOut = gifOut
+	}
+	outcome, err := runSimulator(ctx, c)
+	if err != nil {
+		return err
+	}
+	exitCode = exitCodeForOutcome(outcome)
+	return nil
 }
 
-func initDependencies(c *config) (*dependencies, error) {
+func initDependencies(ctx context.Context, c *config) (*dependencies, error) {
 	deps := &dependencies{}
-	deps.random = simulator.NewRandomSimple()
 	deps.world = simulator.NewWorld()
-	deps.simulator = simulator.NewSimulationEngine(
+	var engine *simulator.SimulationEngine
+	if c.checkpoint != nil {
+		// Resume the simulation from the checkpoint
+		var err error
+		engine, err = simulator.NewSimulationEngineFromCheckpoint(ctx, c.checkpoint, deps.world, nil)
+		if err != nil {
+			return nil, err
+		}
+		engine.SetMaxSteps(c.maxSteps)
+	} else {
+		deps.random = simulator.NewRandomSeeded(c.seed)
+		var err error
+		engine, err = newSimulationEngine(c, deps.world, deps.random)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch c.outputFormat {
+	case outputFormatText, "":
+		engine.AddObserver(simulator.NewTextObserver(c.out))
+	case outputFormatJSONL:
+		engine.AddObserver(simulator.NewJSONLinesObserver(c.out))
+	default:
+		return nil, entity.ErrUnknownOutputFormat
+	}
+	if c.render != "" {
+		renderMode, err := simulator.ParseRenderMode(c.render)
+		if err != nil {
+			return nil, err
+		}
+		engine.AddObserver(simulator.NewRenderObserver(deps.world, renderMode, c.out))
+	}
+	if c.eventsOut != nil {
+		engine.AddObserver(simulator.NewJSONLinesObserver(c.eventsOut))
+	}
+	switch c.reportFormat {
+	case "":
+	case reportFormatJSON, reportFormatYAML:
+		deps.report = simulator.NewReportObserver()
+		if c.checkpoint != nil {
+			deps.report = simulator.NewReportObserverFromCheckpoint(c.checkpoint)
+		}
+		engine.AddObserver(deps.report)
+	default:
+		return nil, entity.ErrUnknownReportFormat
+	}
+	if c.dotOut != nil {
+		engine.AddObserver(simulator.NewDotObserver(deps.world, c.dotOut))
+	}
+	if c.pngOut != nil {
+		engine.AddObserver(simulator.NewPNGObserver(deps.world, c.pngOut))
+	}
+	if c.gifOut != nil {
+		gifObserver := simulator.NewGIFObserver(deps.world, c.gifOut)
+		gifObserver.SetFrameInterval(c.gifInterval)
+		engine.AddObserver(gifObserver)
+	}
+	if c.checkpointFile != "" {
+		engine.AddObserver(simulator.NewCheckpointObserver(engine, c.checkpointFile, c.checkpointInterval))
+	}
+	deps.simulator = engine
+	return deps, nil
+}
+
+// newSimulationEngine creates a simulation engine configured with the simulation parameters
+func newSimulationEngine(c *config, world simulator.WorldStorer, random simulator.Randomer) (*simulator.SimulationEngine, error) {
+	engine := simulator.NewSimulationEngine(
 		c.totalAliens,
 		c.maxSteps,
-		deps.world,
-		deps.random,
+		world,
+		random,
 		c.in,
-		c.out)
-	return deps, nil
+		nil)
+	mapReader, err := simulator.NewMapReader(c.mapFormat, c.inputName)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetMapReader(mapReader)
+	engine.SetTwoWayLinks(c.twoWay)
+	if c.stepMode != "" {
+		stepMode, err := simulator.ParseStepMode(c.stepMode)
+		if err != nil {
+			return nil, err
+		}
+		engine.SetStepMode(stepMode)
+	}
+	movement, err := simulator.NewMovementStrategy(c.movement, c.stayProb)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetMovementStrategy(movement)
+	if c.linkCheck != "" {
+		linkCheckMode, err := simulator.ParseLinkCheckMode(c.linkCheck)
+		if err != nil {
+			return nil, err
+		}
+		engine.SetLinkCheckMode(linkCheckMode)
+	}
+	return engine, nil
 }
 
-func runSimulator(ctx context.Context, c *config) error {
+func runSimulator(ctx context.Context, c *config) (*simulator.Outcome, error) {
 	//Init dependencies
-	deps, err := initDependencies(c)
+	deps, err := initDependencies(ctx, c)
+	if err != nil {
+		log.WithError(err).Error("an error occurred on init")
+		return nil, err
+	}
+
+	// Echo seed so that the run can be replayed
+	_, err = fmt.Fprintf(c.errOut, "Seed: %d\n", c.seed)
 	if err != nil {
-		log.WithError(err).Fatal("an error occurred on init")
+		return nil, err
+	}
+	if c.checkpoint != nil {
+		_, err = fmt.Fprintf(c.errOut, "Resumed at step %d\n", c.checkpoint.Step)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Run simulator
-	return deps.simulator.Run(ctx)
+	// A cancelled simulation is finalized, so that its partial state is reported
+	err = deps.simulator.Run(ctx)
+	if err != nil && !errors.Is(err, entity.ErrContextCancelled) {
+		return nil, err
+	}
+
+	// Write report
+	err = writeReport(deps, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write final map
+	err = writeFinalMap(ctx, deps, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write summary
+	outcome, err := deps.simulator.Outcome(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(c.errOut, "Simulation ended after %d steps: %s (%d alive cities, %d untrapped aliens)\n",
+		outcome.Steps, outcome.Reason, outcome.AliveCities, outcome.UntrappedAliens)
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func writeReport(deps *dependencies, c *config) error {
+	if deps.report == nil || deps.report.Report() == nil {
+		return nil
+	}
+	reportOut := c.reportOut
+	if reportOut == nil {
+		reportOut = c.out
+	}
+	switch c.reportFormat {
+	case reportFormatYAML:
+		return deps.report.Report().WriteYAML(reportOut)
+	default:
+		return deps.report.Report().WriteJSON(reportOut)
+	}
+}
+
+// writeFinalMap writes the world map of the surviving cities, so that it can be fed back as input
+func writeFinalMap(ctx context.Context, deps *dependencies, c *config) error {
+	if c.finalMapFile == "" {
+		return nil
+	}
+	mapWriter, err := simulator.NewMapWriter("", c.finalMapFile)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(c.finalMapFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = out.Close() }()
+	return mapWriter.Write(ctx, deps.world, out)
 }
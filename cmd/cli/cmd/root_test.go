@@ -1,14 +1,23 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"image/gif"
+	"image/png"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
 	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
 )
 
@@ -74,16 +83,555 @@ City1 test=City2
 
 			in := io.NopCloser(strings.NewReader(tt.input))
 			out := &bytes.Buffer{}
+			errOut := &bytes.Buffer{}
 
 			c := &config{
 				totalAliens: tt.giveTotalAliens,
 				maxSteps:    tt.giveMaxSteps,
+				seed:        42,
 				in:          in,
 				out:         out,
+				errOut:      errOut,
 			}
-			err := runSimulator(ctx, c)
-			require.Equal(t, tt.wantError, err)
+			_, err := runSimulator(ctx, c)
+			require.ErrorIs(t, err, tt.wantError)
+			require.True(t, strings.HasPrefix(errOut.String(), "Seed: 42\n"))
 		})
 	}
 
+	t.Run("Case 6: same seed replays same output", func(t *testing.T) {
+		ctx := context.Background()
+
+		outputs := make([]string, 0, 2)
+		for i := 0; i < 2; i++ {
+			out := &bytes.Buffer{}
+			c := &config{
+				totalAliens: 4,
+				maxSteps:    10000,
+				seed:        1234,
+				in:          io.NopCloser(strings.NewReader(inputOK)),
+				out:         out,
+				errOut:      &bytes.Buffer{},
+			}
+			_, err := runSimulator(ctx, c)
+			require.NoError(t, err)
+			outputs = append(outputs, out.String())
+		}
+		require.Equal(t, outputs[0], outputs[1])
+	})
+}
+
+func Test_runSimulator_OutputFormat(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	// countJSONLines checks that each line is a JSON object and returns the number of lines
+	countJSONLines := func(t *testing.T, r io.Reader) int {
+		total := 0
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			event := make(map[string]interface{})
+			err := json.Unmarshal(scanner.Bytes(), &event)
+			require.NoError(t, err)
+			require.NotEmpty(t, event["type"])
+			total++
+		}
+		return total
+	}
+
+	t.Run("Case 1: JSON Lines output", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		c := &config{
+			totalAliens:  2,
+			maxSteps:     100,
+			seed:         42,
+			outputFormat: outputFormatJSONL,
+			in:           io.NopCloser(strings.NewReader(input)),
+			out:          out,
+			errOut:       &bytes.Buffer{},
+		}
+		_, err := runSimulator(context.Background(), c)
+		require.NoError(t, err)
+		require.NotContains(t, out.String(), "has been destroyed by")
+		require.Greater(t, countJSONLines(t, out), 0)
+	})
+
+	t.Run("Case 2: Text output and events file", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		eventsOut := &bytes.Buffer{}
+		c := &config{
+			totalAliens:  2,
+			maxSteps:     100,
+			seed:         42,
+			outputFormat: outputFormatText,
+			in:           io.NopCloser(strings.NewReader(input)),
+			out:          out,
+			errOut:       &bytes.Buffer{},
+			eventsOut:    eventsOut,
+		}
+		_, err := runSimulator(context.Background(), c)
+		require.NoError(t, err)
+		require.NotContains(t, out.String(), "{")
+		require.Greater(t, countJSONLines(t, eventsOut), 0)
+	})
+
+	t.Run("Case 3: Unknown output format", func(t *testing.T) {
+		c := &config{
+			totalAliens:  2,
+			maxSteps:     100,
+			seed:         42,
+			outputFormat: "xml",
+			in:           io.NopCloser(strings.NewReader(input)),
+			out:          &bytes.Buffer{},
+			errOut:       &bytes.Buffer{},
+		}
+		_, err := runSimulator(context.Background(), c)
+		require.ErrorIs(t, err, entity.ErrUnknownOutputFormat)
+	})
+}
+
+func Test_runSimulator_StepMode(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name, giveStepMode string
+		wantError          error
+	}{
+		{
+			name:         "Case 1: sequential step mode",
+			giveStepMode: simulator.Sequential.String(),
+			wantError:    nil,
+		},
+		{
+			name:         "Case 2: simultaneous step mode",
+			giveStepMode: simulator.Simultaneous.String(),
+			wantError:    nil,
+		},
+		{
+			name:         "Case 3: unknown step mode",
+			giveStepMode: "random",
+			wantError:    entity.ErrUnknownStepMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{
+				totalAliens: 2,
+				maxSteps:    100,
+				seed:        42,
+				stepMode:    tt.giveStepMode,
+				in:          io.NopCloser(strings.NewReader(input)),
+				out:         &bytes.Buffer{},
+				errOut:      &bytes.Buffer{},
+			}
+			_, err := runSimulator(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+		})
+	}
+}
+
+func Test_runSimulator_Movement(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name, giveMovement  string
+		giveStayProbability float64
+		wantError           error
+	}{
+		{
+			name:         "Case 1: uniform movement",
+			giveMovement: simulator.UniformMovementName,
+			wantError:    nil,
+		},
+		{
+			name:                "Case 2: stay movement",
+			giveMovement:        simulator.StayMovementName,
+			giveStayProbability: 0.9,
+			wantError:           nil,
+		},
+		{
+			name:                "Case 3: stay movement with invalid probability",
+			giveMovement:        simulator.StayMovementName,
+			giveStayProbability: -1,
+			wantError:           entity.ErrInvalidProbability,
+		},
+		{
+			name:         "Case 4: seek movement",
+			giveMovement: simulator.SeekMovementName,
+			wantError:    nil,
+		},
+		{
+			name:         "Case 5: unknown movement",
+			giveMovement: "teleport",
+			wantError:    entity.ErrUnknownMovementStrategy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{
+				totalAliens: 2,
+				maxSteps:    100,
+				seed:        42,
+				movement:    tt.giveMovement,
+				stayProb:    tt.giveStayProbability,
+				in:          io.NopCloser(strings.NewReader(input)),
+				out:         &bytes.Buffer{},
+				errOut:      &bytes.Buffer{},
+			}
+			_, err := runSimulator(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+		})
+	}
+}
+
+func Test_runSimulator_Report(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name, giveReportFormat string
+		wantContains           string
+		wantError              error
+	}{
+		{
+			name:             "Case 1: JSON report",
+			giveReportFormat: reportFormatJSON,
+			wantContains:     `"termination_reason": `,
+			wantError:        nil,
+		},
+		{
+			name:             "Case 2: YAML report",
+			giveReportFormat: reportFormatYAML,
+			wantContains:     "termination_reason: ",
+			wantError:        nil,
+		},
+		{
+			name:             "Case 3: Unknown report format",
+			giveReportFormat: "xml",
+			wantError:        entity.ErrUnknownReportFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			reportOut := &bytes.Buffer{}
+			c := &config{
+				totalAliens:  2,
+				maxSteps:     100,
+				seed:         42,
+				reportFormat: tt.giveReportFormat,
+				in:           io.NopCloser(strings.NewReader(input)),
+				out:          out,
+				errOut:       &bytes.Buffer{},
+				reportOut:    reportOut,
+			}
+			_, err := runSimulator(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+			require.Contains(t, reportOut.String(), tt.wantContains)
+		})
+	}
+}
+
+func Test_runSimulator_Outcome(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name                          string
+		giveInput                     string
+		giveTotalAliens, giveMaxSteps uint
+		wantReason                    simulator.TerminationReason
+		wantExitCode                  int
+	}{
+		{
+			name:            "Case 1: max steps reached",
+			giveInput:       input,
+			giveTotalAliens: 1,
+			giveMaxSteps:    5,
+			wantReason:      simulator.MaxStepsReached,
+			wantExitCode:    exitCodeMaxStepsReached,
+		},
+		{
+			name:            "Case 2: all aliens trapped",
+			giveInput:       input,
+			giveTotalAliens: 0,
+			giveMaxSteps:    5,
+			wantReason:      simulator.AllAliensTrapped,
+			wantExitCode:    exitCodeSuccess,
+		},
+		{
+			name:            "Case 3: all cities destroyed",
+			giveInput:       "A\n",
+			giveTotalAliens: 2,
+			giveMaxSteps:    5,
+			wantReason:      simulator.AllCitiesDestroyed,
+			wantExitCode:    exitCodeSuccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errOut := &bytes.Buffer{}
+			c := &config{
+				totalAliens: tt.giveTotalAliens,
+				maxSteps:    tt.giveMaxSteps,
+				seed:        42,
+				in:          io.NopCloser(strings.NewReader(tt.giveInput)),
+				out:         &bytes.Buffer{},
+				errOut:      errOut,
+			}
+			outcome, err := runSimulator(context.Background(), c)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReason, outcome.Reason)
+			require.Equal(t, tt.wantExitCode, exitCodeForOutcome(outcome))
+			require.Contains(t, errOut.String(), tt.wantReason.String())
+		})
+	}
+
+	t.Run("Case 4: cancelled", func(t *testing.T) {
+		require.Equal(t, exitCodeCancelled, exitCodeForOutcome(&simulator.Outcome{Reason: simulator.Cancelled}))
+	})
+}
+
+func Test_runSimulator_Cancelled(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	reportOut := &bytes.Buffer{}
+	checkpointFilepath := filepath.Join(t.TempDir(), "checkpoint.json")
+	c := &config{
+		totalAliens:    1,
+		maxSteps:       100,
+		seed:           42,
+		reportFormat:   reportFormatJSON,
+		in:             io.NopCloser(strings.NewReader(input)),
+		out:            out,
+		errOut:         errOut,
+		reportOut:      reportOut,
+		checkpointFile: checkpointFilepath,
+	}
+	outcome, err := runSimulator(ctx, c)
+	require.NoError(t, err)
+	require.Equal(t, simulator.Cancelled, outcome.Reason)
+	require.Equal(t, exitCodeCancelled, exitCodeForOutcome(outcome))
+
+	// Partial state is reported
+	require.Equal(t, "\nCity1 north=City2 east=City3\nCity2 south=City1\nCity3 west=City1\n", out.String())
+	require.Contains(t, errOut.String(), "Simulation ended after 0 steps: cancelled (3 alive cities, 1 untrapped aliens)")
+	require.Contains(t, reportOut.String(), `"termination_reason": "cancelled"`)
+
+	// Checkpoint is written
+	checkpoint, err := readCheckpointFile(checkpointFilepath)
+	require.NoError(t, err)
+	require.Equal(t, uint(0), checkpoint.Step)
+	require.Len(t, checkpoint.Aliens, 1)
+}
+
+func Test_runSimulator_FinalMap(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1 east=City4
+City3 west=City1
+City4 west=City2
+`
+
+	for _, fileName := range []string{"final.txt", "final.json", "final.yaml"} {
+		t.Run(fileName, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			finalMapFilepath := filepath.Join(t.TempDir(), fileName)
+			c := &config{
+				totalAliens:  4,
+				maxSteps:     100,
+				seed:         42,
+				in:           io.NopCloser(strings.NewReader(input)),
+				out:          out,
+				errOut:       &bytes.Buffer{},
+				finalMapFile: finalMapFilepath,
+			}
+			_, err := runSimulator(context.Background(), c)
+			require.NoError(t, err)
+
+			// The final map can be read back and lists the surviving cities
+			in, err := os.Open(finalMapFilepath)
+			require.NoError(t, err)
+			defer func() { _ = in.Close() }()
+			mapReader, err := simulator.NewMapReader("", finalMapFilepath)
+			require.NoError(t, err)
+			definition, err := mapReader.Read(in)
+			require.NoError(t, err)
+			world := simulator.NewWorld()
+			require.NoError(t, definition.LoadToWorld(context.Background(), world))
+			cities, err := world.GetAliveCities(context.Background())
+			require.NoError(t, err)
+			survivors := make([]string, 0, len(cities))
+			for _, city := range cities {
+				survivors = append(survivors, city.String())
+			}
+			printed := strings.Split(strings.TrimSpace(out.String()[strings.LastIndex(out.String(), "\n\n"):]), "\n")
+			require.ElementsMatch(t, printed, survivors)
+		})
+	}
+}
+
+func Test_runSimulator_Dot(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	dotOut := &bytes.Buffer{}
+	c := &config{
+		totalAliens: 1,
+		maxSteps:    0,
+		seed:        42,
+		in:          io.NopCloser(strings.NewReader(input)),
+		out:         &bytes.Buffer{},
+		errOut:      &bytes.Buffer{},
+		dotOut:      dotOut,
+	}
+	_, err := runSimulator(context.Background(), c)
+	require.NoError(t, err)
+	require.Contains(t, dotOut.String(), `"City1" -> "City2" [label=north];`)
+	require.Contains(t, dotOut.String(), `\nAlien #1"];`)
+}
+
+func Test_runSimulator_Images(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	pngOut, gifOut := &bytes.Buffer{}, &bytes.Buffer{}
+	c := &config{
+		totalAliens: 1,
+		maxSteps:    3,
+		seed:        42,
+		in:          io.NopCloser(strings.NewReader(input)),
+		out:         &bytes.Buffer{},
+		errOut:      &bytes.Buffer{},
+		pngOut:      pngOut,
+		gifOut:      gifOut,
+		gifInterval: 1,
+	}
+	_, err := runSimulator(context.Background(), c)
+	require.NoError(t, err)
+	_, err = png.Decode(pngOut)
+	require.NoError(t, err)
+	animation, err := gif.DecodeAll(gifOut)
+	require.NoError(t, err)
+	require.Len(t, animation.Image, 4)
+}
+
+func Test_runSimulator_Render(t *testing.T) {
+	log.SetLevel(log.WarnLevel)
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name       string
+		giveRender string
+		wantOut    string
+		wantError  error
+	}{
+		{
+			name:       "Case 1: final render",
+			giveRender: "final",
+			wantOut:    "\nCity1 north=City2 east=City3\nCity2 south=City1\nCity3 west=City1\n\nCity2\n|\nCity1---City3[1]\n",
+		},
+		{
+			name:       "Case 2: unknown render mode",
+			giveRender: "always",
+			wantError:  entity.ErrUnknownRenderMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			c := &config{
+				totalAliens: 1,
+				maxSteps:    0,
+				seed:        42,
+				in:          io.NopCloser(strings.NewReader(input)),
+				out:         out,
+				errOut:      &bytes.Buffer{},
+				render:      tt.giveRender,
+			}
+			_, err := runSimulator(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
+
+func Test_Shorthands(t *testing.T) {
+	// A shorthand stands for the same flag in every command
+	flagNames := make(map[string]string)
+	commands := []*cobra.Command{rootCmd}
+	for len(commands) > 0 {
+		command := commands[0]
+		commands = append(commands[1:], command.Commands()...)
+		command.Flags().VisitAll(func(flag *pflag.Flag) {
+			if flag.Shorthand == "" || flag.Name == "help" {
+				return
+			}
+			if flagName, found := flagNames[flag.Shorthand]; found {
+				require.Equal(t, flagName, flag.Name, "shorthand -%s of command %q", flag.Shorthand, command.CommandPath())
+				return
+			}
+			flagNames[flag.Shorthand] = flag.Name
+		})
+	}
 }
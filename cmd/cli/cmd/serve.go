@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+)
+
+// serverShutdownTimeout is the maximum duration of a graceful shutdown of the server
+const serverShutdownTimeout = 5 * time.Second
+
+// serveCmd represents the serve command
+var (
+	// Flags
+	serveAddress string
+
+	// Commands
+	serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a local HTTP/JSON API to run simulations",
+		Long: `Serve a local HTTP/JSON API to create simulations from a world map and parameters,
+step them, run them to completion, fetch their current world and events, and delete them.
+The events of a simulation can also be streamed in real time as server-sent events.
+Every simulation has its own world, random generator and simulation engine.
+The server is shut down gracefully on SIGINT or SIGTERM.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := &serveConfig{
+				address: serveAddress,
+				errOut:  cmd.ErrOrStderr(),
+			}
+			return runServe(cmd.Context(), c)
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	serveCmd.Flags().StringVarP(&serveAddress, "address", "a", "localhost:8080", "address the server listens on")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+type serveConfig struct {
+	address string
+	errOut  io.Writer
+}
+
+// runServe serves the simulations API until the context is cancelled
+func runServe(ctx context.Context, c *serveConfig) error {
+	listener, err := net.Listen("tcp", c.address)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           simulator.NewServer(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	_, err = fmt.Fprintf(c.errOut, "Listening on http://%s\n", listener.Addr())
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	select {
+	case err = <-served:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		err = server.Shutdown(shutdownCtx)
+		if err != nil {
+			return err
+		}
+		err = <-served
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
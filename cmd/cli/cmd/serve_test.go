@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+)
+
+func Test_runServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errOutReader, errOut := io.Pipe()
+	c := &serveConfig{
+		address: "127.0.0.1:0",
+		errOut:  errOut,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- runServe(ctx, c)
+	}()
+
+	// The address is echoed once the server listens
+	line, err := bufio.NewReader(errOutReader).ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "Listening on http://"))
+	url := strings.TrimSpace(strings.TrimPrefix(line, "Listening on "))
+
+	res, err := http.Post(url+"/simulations", "application/json", strings.NewReader(`{"map": "City1 north=City2\nCity2 south=City1", "aliens": 1, "seed": 42}`))
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	simulation := &simulator.ServerSimulation{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(simulation))
+	require.Equal(t, "1", simulation.ID)
+	require.Equal(t, int64(42), simulation.Seed)
+
+	// The open event streams don't prevent the server from shutting down
+	stream, err := http.Get(url + "/simulations/1/stream")
+	require.NoError(t, err)
+	defer func() { _ = stream.Body.Close() }()
+	require.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+	cancel()
+	require.NoError(t, <-served)
+	_, err = io.ReadAll(stream.Body)
+	require.NoError(t, err)
+}
+
+func Test_runServe_InvalidAddress(t *testing.T) {
+	c := &serveConfig{
+		address: "invalid address",
+		errOut:  io.Discard,
+	}
+	require.Error(t, runServe(context.Background(), c))
+}
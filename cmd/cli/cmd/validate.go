@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+)
+
+// validateCmd represents the validate command
+var (
+	// Flags
+	validateMapFilepath string
+	validateMapFormat   string
+	validateTwoWay      bool
+
+	// Commands
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate a world map",
+		Long: `Validate a world map and report all its problems with their file:line:column positions:
+malformed direction=city tokens, unknown directions, duplicate directions on a line, self links and conflicting links.
+With the two-way flag, the reverse links conflicting with an existing link are also reported as problems.
+The links declared twice and the geometrically inconsistent links (missing, conflicting or contradictory reverse links) are reported as warnings.
+The command exits with code 1 when a problem is found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := os.Open(validateMapFilepath)
+			defer func() { _ = in.Close() }()
+			if err != nil {
+				return err
+			}
+			c := &validateConfig{
+				in:        in,
+				inputName: validateMapFilepath,
+				mapFormat: validateMapFormat,
+				twoWay:    validateTwoWay,
+				out:       cmd.OutOrStdout(),
+			}
+			valid, err := runValidate(cmd.Context(), c)
+			if err != nil {
+				return err
+			}
+			if !valid {
+				exitCode = exitCodeError
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	validateCmd.Flags().StringVarP(&validateMapFilepath, "file", "m", "map.txt", "world map file path")
+	validateCmd.Flags().StringVarP(&validateMapFormat, "map-format", "M", "", "world map format (text, json, yaml), detected from the file extension by default")
+	validateCmd.Flags().BoolVarP(&validateTwoWay, "two-way", "u", false, "treat every link as a two-way road and add its reverse link")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+type validateConfig struct {
+	in        io.Reader
+	inputName string
+	mapFormat string
+	twoWay    bool
+	out       io.Writer
+}
+
+// runValidate parses the world map, writes its problems and tells if it is valid
+func runValidate(ctx context.Context, c *validateConfig) (bool, error) {
+	mapReader, err := simulator.NewMapReader(c.mapFormat, c.inputName)
+	if err != nil {
+		return false, err
+	}
+	mapReader.SetCollectAll(true)
+	definition, err := mapReader.Read(c.in)
+	var parseErr *simulator.ParseError
+	if errors.As(err, &parseErr) {
+		return false, writeParseIssues(c, parseErr)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Load the world
+	world := simulator.NewWorld()
+	err = definition.LoadToWorld(ctx, world)
+	if err != nil {
+		return false, err
+	}
+	if c.twoWay {
+		err = definition.AddReverseLinks(ctx, world)
+		if errors.As(err, &parseErr) {
+			return false, writeParseIssues(c, parseErr)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Report the links declared twice
+	for _, warning := range definition.Warnings {
+		_, err = fmt.Fprintln(c.out, warning)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Check the geometry of the links
+	issues, err := simulator.CheckLinks(ctx, world)
+	if err != nil {
+		return false, err
+	}
+	for _, issue := range issues {
+		_, err = fmt.Fprintf(c.out, "%s: warning: %s\n", c.inputName, issue)
+		if err != nil {
+			return false, err
+		}
+	}
+	_, err = fmt.Fprintf(c.out, "%s: %d cities, %d links, no problem found, %d inconsistent links\n", c.inputName, len(definition.CityNames()), definition.TotalLinks(), len(issues))
+	return true, err
+}
+
+// writeParseIssues writes the problems of a world map
+func writeParseIssues(c *validateConfig, parseErr *simulator.ParseError) error {
+	for _, issue := range parseErr.Issues {
+		_, err := fmt.Fprintln(c.out, issue)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(c.out, "%s: %d problems found\n", c.inputName, len(parseErr.Issues))
+	return err
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		giveFormat string
+		giveTwoWay bool
+		wantValid  bool
+		wantOut    string
+	}{
+		{
+			name:      "Case 1: valid map",
+			input:     "City1 north=City2\nCity2 south=City1 west=City3\nCity3 east=City2\n",
+			wantValid: true,
+			wantOut:   "map.txt: 3 cities, 4 links, no problem found, 0 inconsistent links\n",
+		},
+		{
+			name:      "Case 2: valid map with inconsistent links",
+			input:     "City1 north=City2 east=City3\nCity2 south=City1\nCity3 east=City1\n",
+			wantValid: true,
+			wantOut: `map.txt: warning: City1 east=City3 but City3 east=City1
+map.txt: 3 cities, 4 links, no problem found, 1 inconsistent links
+`,
+		},
+		{
+			name:       "Case 3: YAML map",
+			input:      "metadata:\n  name: Test\ncities:\n  - name: City 1\n    links:\n      north: City 2\n  - name: City 2\n    links:\n      south: City 1\n",
+			giveFormat: "yaml",
+			wantValid:  true,
+			wantOut:    "map.txt: 2 cities, 2 links, no problem found, 0 inconsistent links\n",
+		},
+		{
+			name:       "Case 4: two-way map",
+			input:      "City1 north=City2\nCity2 west=City3\n",
+			giveTwoWay: true,
+			wantValid:  true,
+			wantOut:    "map.txt: 3 cities, 2 links, no problem found, 0 inconsistent links\n",
+		},
+		{
+			name:       "Case 5: two-way map with a reverse link conflict",
+			input:      "City1 north=City2\nCity2 south=City3\n",
+			giveTwoWay: true,
+			wantValid:  false,
+			wantOut: `map.txt:1:7: reverse link City2 south=City1 of City1 north=City2 conflicts with City2 south=City3
+map.txt: 1 problems found
+`,
+		},
+		{
+			name:      "Case 6: invalid map",
+			input:     "City1 north=City2 up=City3\nCity2 south=City2\n",
+			wantValid: false,
+			wantOut: `map.txt:1:19: unknown direction "up", expected north, east, south or west
+map.txt:2:7: link from city "City2" to itself
+map.txt: 2 problems found
+`,
+		},
+		{
+			name:      "Case 7: valid map with a link declared twice",
+			input:     "City1 north=City2\nCity2 south=City1\nCity1 north=City2\n",
+			wantValid: true,
+			wantOut: `map.txt:3:7: warning: duplicate link north of city "City1" to "City2", already declared at line 1
+map.txt: 2 cities, 3 links, no problem found, 0 inconsistent links
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			c := &validateConfig{
+				in:        strings.NewReader(tt.input),
+				inputName: "map.txt",
+				mapFormat: tt.giveFormat,
+				twoWay:    tt.giveTwoWay,
+				out:       out,
+			}
+			valid, err := runValidate(context.Background(), c)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantValid, valid)
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// watchCmd represents the watch command
+var (
+	// Flags
+	watchTotalAliens uint
+	watchMaxSteps    uint
+	watchMapFilepath string
+	watchMapFormat   string
+	watchSeed        int64
+	watchStepMode    string
+	watchMovement    string
+	watchStayProb    float64
+	watchLinkCheck   string
+	watchTwoWay      bool
+	watchDelay       time.Duration
+
+	// Commands
+	watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Watch a simulation live in the terminal",
+		Long: `Run a simulation step by step and re-render the world in the terminal after every step,
+with the alien positions, the recent destructions, the step counter and the remaining aliens.
+Press space to pause or resume the simulation, n to simulate the next step while it is paused, and q to quit.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := os.Open(watchMapFilepath)
+			defer func() { _ = in.Close() }()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("seed") {
+				watchSeed = time.Now().UnixNano()
+			}
+			c := &watchConfig{
+				config: config{
+					totalAliens: watchTotalAliens,
+					maxSteps:    watchMaxSteps,
+					seed:        watchSeed,
+					stepMode:    watchStepMode,
+					movement:    watchMovement,
+					stayProb:    watchStayProb,
+					in:          in,
+					inputName:   watchMapFilepath,
+					mapFormat:   watchMapFormat,
+					out:         cmd.OutOrStdout(),
+					errOut:      cmd.ErrOrStderr(),
+					linkCheck:   watchLinkCheck,
+					twoWay:      watchTwoWay,
+				},
+				delay: watchDelay,
+			}
+
+			// Read the keys as soon as they are pressed
+			restoreTerminal := setRawTerminal(os.Stdin)
+			defer restoreTerminal()
+			c.keys = readKeys(os.Stdin)
+
+			outcome, err := runWatch(cmd.Context(), c)
+			if err != nil {
+				return err
+			}
+			exitCode = exitCodeForOutcome(outcome)
+			return nil
+		},
+	}
+)
+
+func init() {
+	// Flag setup
+	watchCmd.Flags().UintVarP(&watchTotalAliens, "aliens", "n", 5, "total number of aliens")
+	watchCmd.Flags().UintVarP(&watchMaxSteps, "steps", "s", 10000, "maximum number of steps")
+	watchCmd.Flags().StringVarP(&watchMapFilepath, "file", "m", "map.txt", "world map file path")
+	watchCmd.Flags().StringVarP(&watchMapFormat, "map-format", "M", "", "world map format (text, json, yaml), detected from the file extension by default")
+	watchCmd.Flags().Int64VarP(&watchSeed, "seed", "r", 0, "random generator seed (defaults to a time based seed)")
+	watchCmd.Flags().StringVarP(&watchStepMode, "step-mode", "t", simulator.Sequential.String(), "step semantics (sequential, simultaneous)")
+	watchCmd.Flags().StringVarP(&watchMovement, "movement", "b", simulator.UniformMovementName, "alien movement strategy (uniform, stay, unvisited, lazy, seek)")
+	watchCmd.Flags().Float64VarP(&watchStayProb, "stay-probability", "q", 0.5, "probability that an alien stays in place with the stay movement strategy")
+	watchCmd.Flags().StringVarP(&watchLinkCheck, "link-check", "l", simulator.LinkCheckOff.String(), "what is done with geometrically inconsistent links (off, warn, reject, repair)")
+	watchCmd.Flags().BoolVarP(&watchTwoWay, "two-way", "u", false, "treat every link as a two-way road and add its reverse link")
+	watchCmd.Flags().DurationVar(&watchDelay, "delay", 200*time.Millisecond, "delay between two steps")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+type watchConfig struct {
+	config
+	delay time.Duration
+	keys  <-chan byte
+}
+
+// runWatch runs the simulation with a watcher re-rendering the world after every step
+func runWatch(ctx context.Context, c *watchConfig) (*simulator.Outcome, error) {
+	world := simulator.NewWorld()
+	engine, err := newSimulationEngine(&c.config, world, simulator.NewRandomSeeded(c.seed))
+	if err != nil {
+		return nil, err
+	}
+	watcher := simulator.NewWatcher(engine, world, c.out)
+	watcher.SetDelay(c.delay)
+	watcher.SetKeys(c.keys)
+	engine.AddObserver(watcher)
+
+	// A cancelled simulation is still reported
+	err = watcher.Run(ctx)
+	if err != nil && !errors.Is(err, entity.ErrContextCancelled) {
+		return nil, err
+	}
+
+	// Echo seed so that the run can be replayed
+	outcome, err := engine.Outcome(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(c.errOut, "Seed: %d\nSimulation ended after %d steps: %s (%d alive cities, %d untrapped aliens)\n",
+		c.seed, outcome.Steps, outcome.Reason, outcome.AliveCities, outcome.UntrappedAliens)
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+// readKeys reads the keys pressed in the background
+// The channel is closed when the input can't be read anymore
+func readKeys(in io.Reader) <-chan byte {
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buffer := make([]byte, 1)
+		for {
+			_, err := in.Read(buffer)
+			if err != nil {
+				return
+			}
+			keys <- buffer[0]
+		}
+	}()
+	return keys
+}
+
+// setRawTerminal disables the line buffering and the echo of a terminal with stty, so that the keys are read as soon as they are pressed
+// It returns a function restoring the terminal, and leaves the input untouched if it is not a terminal
+func setRawTerminal(in *os.File) func() {
+	info, err := in.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return func() {}
+	}
+	state, err := stty(in, "-g")
+	if err != nil {
+		return func() {}
+	}
+	_, err = stty(in, "-icanon", "-echo", "min", "1")
+	if err != nil {
+		return func() {}
+	}
+	return func() { _, _ = stty(in, strings.TrimSpace(state)) }
+}
+
+// stty runs the stty command on a terminal
+func stty(in *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = in
+	out, err := cmd.Output()
+	return string(out), err
+}
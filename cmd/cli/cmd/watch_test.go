@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator"
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_runWatch(t *testing.T) {
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name       string
+		giveKeys   string
+		giveMove   string
+		wantReason simulator.TerminationReason
+		wantErrOut string
+		wantError  error
+	}{
+		{
+			name:       "Case 1: run to completion",
+			giveMove:   simulator.UniformMovementName,
+			wantReason: simulator.MaxStepsReached,
+			wantErrOut: "Seed: 42\nSimulation ended after 3 steps: max_steps_reached (3 alive cities, 1 untrapped aliens)\n",
+		},
+		{
+			name:       "Case 2: quit",
+			giveKeys:   "q",
+			giveMove:   simulator.UniformMovementName,
+			wantReason: simulator.Cancelled,
+			wantErrOut: "Seed: 42\nSimulation ended after 0 steps: cancelled (3 alive cities, 1 untrapped aliens)\n",
+		},
+		{
+			name:      "Case 3: unknown movement",
+			giveMove:  "teleport",
+			wantError: entity.ErrUnknownMovementStrategy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			errOut := &bytes.Buffer{}
+			c := &watchConfig{
+				config: config{
+					totalAliens: 1,
+					maxSteps:    3,
+					seed:        42,
+					movement:    tt.giveMove,
+					in:          io.NopCloser(strings.NewReader(input)),
+					inputName:   "map.txt",
+					out:         out,
+					errOut:      errOut,
+				},
+				keys: readKeys(strings.NewReader(tt.giveKeys)),
+			}
+			if tt.giveKeys != "" {
+				c.delay = time.Hour
+			}
+			outcome, err := runWatch(context.Background(), c)
+			require.ErrorIs(t, err, tt.wantError)
+			if tt.wantError != nil {
+				return
+			}
+			require.Equal(t, tt.wantReason, outcome.Reason)
+			require.Equal(t, tt.wantErrOut, errOut.String())
+			require.Contains(t, out.String(), "City2")
+		})
+	}
+}
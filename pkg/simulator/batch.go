@@ -0,0 +1,484 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+	log "github.com/sirupsen/logrus"
+)
+
+// confidenceZ is the z-score used to compute the 95% confidence intervals
+const confidenceZ = 1.96
+
+// totalHistogramBuckets is the maximum number of buckets of the steps histogram
+const totalHistogramBuckets = 10
+
+// BatchRunner runs the same simulation several times with different seeds
+type BatchRunner struct {
+	// World map input
+	input []byte
+
+	// Number of aliens that are spawned during initialization of each run
+	startAliens uint
+
+	// Maximum number of iterations to simulate for each run
+	maxSteps uint
+
+	// Number of runs
+	totalRuns uint
+
+	// Seed of the first run, the next runs use the following seeds
+	baseSeed int64
+
+	// Number of runs executed in parallel
+	workers int
+
+	// Semantics used to simulate a step of each run
+	stepMode StepMode
+
+	// Strategy used to move the aliens of each run
+	movement MovementStrategy
+
+	// Reader of the world map input
+	mapReader MapReader
+
+	// Are the links of the world of each run two-way roads
+	twoWayLinks bool
+
+	// What is done when the links of the world are geometrically inconsistent
+	linkCheckMode LinkCheckMode
+}
+
+// BatchRun is the result of a single run of a batch
+type BatchRun struct {
+	// Seed of the run
+	Seed int64
+
+	// Outcome of the run
+	Outcome *Outcome
+
+	// Report of the run
+	Report *Report
+}
+
+// NewBatchRunner is a batch runner constructor
+func NewBatchRunner(input []byte, startAliens, maxSteps, totalRuns uint, baseSeed int64, workers int) (*BatchRunner, error) {
+	if totalRuns == 0 {
+		return nil, entity.ErrInvalidRunCount
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &BatchRunner{
+		input:       input,
+		startAliens: startAliens,
+		maxSteps:    maxSteps,
+		totalRuns:   totalRuns,
+		baseSeed:    baseSeed,
+		workers:     workers,
+		mapReader:   NewTextMapReader(""),
+		movement:    NewUniformMovement(),
+	}, nil
+}
+
+// SetStepMode sets the semantics used to simulate a step of each run (defaults to Sequential)
+func (b *BatchRunner) SetStepMode(stepMode StepMode) {
+	b.stepMode = stepMode
+}
+
+// SetMovementStrategy sets the strategy used to move the aliens of each run (defaults to uniform)
+// The strategy is shared by the runs executed in parallel
+func (b *BatchRunner) SetMovementStrategy(movement MovementStrategy) {
+	b.movement = movement
+}
+
+// SetMapReader sets the reader of the world map input (defaults to the text format)
+// The reader is shared by the runs executed in parallel
+func (b *BatchRunner) SetMapReader(mapReader MapReader) {
+	b.mapReader = mapReader
+}
+
+// SetTwoWayLinks sets whether the links of the world of each run are two-way roads (defaults to false)
+func (b *BatchRunner) SetTwoWayLinks(twoWayLinks bool) {
+	b.twoWayLinks = twoWayLinks
+}
+
+// SetLinkCheckMode sets what is done when the links of the world of each run are geometrically inconsistent (defaults to off)
+func (b *BatchRunner) SetLinkCheckMode(linkCheckMode LinkCheckMode) {
+	b.linkCheckMode = linkCheckMode
+}
+
+// Run executes all the runs of the batch and aggregates their statistics
+func (b *BatchRunner) Run(ctx context.Context) (*BatchStatistics, error) {
+	log.WithFields(log.Fields{
+		"runs":    b.totalRuns,
+		"workers": b.workers,
+	}).Info("Batch Run")
+
+	// Retrieve the cities of the map in their input order
+	cityNames, err := b.loadCityNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the runs in parallel
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runs := make([]*BatchRun, b.totalRuns)
+	runIndexes := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		errMutex sync.Mutex
+		runErr   error
+	)
+	for w := 0; w < b.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range runIndexes {
+				run, err := b.runOnce(ctx, b.baseSeed+int64(i))
+				if err != nil {
+					errMutex.Lock()
+					if runErr == nil {
+						runErr = err
+					}
+					errMutex.Unlock()
+					cancel()
+					continue
+				}
+				runs[i] = run
+			}
+		}()
+	}
+	go func() {
+		defer close(runIndexes)
+		for i := 0; i < int(b.totalRuns); i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case runIndexes <- i:
+			}
+		}
+	}()
+	wg.Wait()
+	if runErr != nil {
+		return nil, runErr
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return NewBatchStatistics(b.baseSeed, cityNames, runs), nil
+}
+
+// runOnce executes a single run of the batch with its own world and random generator
+func (b *BatchRunner) runOnce(ctx context.Context, seed int64) (*BatchRun, error) {
+	reportObserver := NewReportObserver()
+	s := NewSimulationEngine(b.startAliens, b.maxSteps, NewWorld(), NewRandomSeeded(seed), bytes.NewReader(b.input), nil)
+	s.SetMapReader(b.mapReader)
+	s.SetStepMode(b.stepMode)
+	s.SetMovementStrategy(b.movement)
+	s.SetTwoWayLinks(b.twoWayLinks)
+	s.SetLinkCheckMode(b.linkCheckMode)
+	s.AddObserver(reportObserver)
+	err := s.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	outcome, err := s.Outcome(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchRun{
+		Seed:    seed,
+		Outcome: outcome,
+		Report:  reportObserver.Report(),
+	}, nil
+}
+
+// loadCityNames retrieves the names of the cities of the map in their input order
+func (b *BatchRunner) loadCityNames(ctx context.Context) ([]string, error) {
+	world := NewWorld()
+	s := NewSimulationEngine(0, 0, world, NewRandomSeeded(b.baseSeed), bytes.NewReader(b.input), nil)
+	s.SetMapReader(b.mapReader)
+	err := s.loadInputToWorld(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := world.GetAliveCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cityNames := make([]string, 0, len(cities))
+	for _, city := range cities {
+		cityNames = append(cityNames, city.Name)
+	}
+	return cityNames, nil
+}
+
+// BatchStatistics represents the aggregated statistics of a batch of runs
+type BatchStatistics struct {
+	// Number of runs
+	Runs int `json:"runs"`
+
+	// Seed of the first run
+	FirstSeed int64 `json:"first_seed"`
+
+	// Seed of the last run
+	LastSeed int64 `json:"last_seed"`
+
+	// Number of runs per termination reason
+	TerminationReasons map[string]int `json:"termination_reasons"`
+
+	// Distribution of the steps to termination
+	Steps *SampleStatistics `json:"steps"`
+
+	// Histogram of the steps to termination
+	StepsHistogram []*HistogramBucket `json:"steps_histogram"`
+
+	// Distribution of the number of destroyed cities
+	DestroyedCities *SampleStatistics `json:"destroyed_cities"`
+
+	// Survival probability of each city
+	CitySurvivals []*CitySurvival `json:"city_survivals"`
+}
+
+// SampleStatistics represents the statistics of a sample of values
+type SampleStatistics struct {
+	// Mean of the values
+	Mean float64 `json:"mean"`
+
+	// Standard deviation of the values
+	StdDev float64 `json:"std_dev"`
+
+	// Lower bound of the 95% confidence interval of the mean
+	MeanLow float64 `json:"mean_ci95_low"`
+
+	// Upper bound of the 95% confidence interval of the mean
+	MeanHigh float64 `json:"mean_ci95_high"`
+
+	// Minimum value
+	Min float64 `json:"min"`
+
+	// First quartile
+	P25 float64 `json:"p25"`
+
+	// Median
+	P50 float64 `json:"p50"`
+
+	// Third quartile
+	P75 float64 `json:"p75"`
+
+	// 90th percentile
+	P90 float64 `json:"p90"`
+
+	// Maximum value
+	Max float64 `json:"max"`
+}
+
+// HistogramBucket is a bucket of a histogram holding the values in [From, To)
+type HistogramBucket struct {
+	// Lower bound of the bucket (included)
+	From uint `json:"from"`
+
+	// Upper bound of the bucket (excluded)
+	To uint `json:"to"`
+
+	// Number of values in the bucket
+	Count int `json:"count"`
+}
+
+// CitySurvival represents the survival probability of a city
+type CitySurvival struct {
+	// City name
+	Name string `json:"name"`
+
+	// Survival probability
+	Probability float64 `json:"probability"`
+
+	// Lower bound of the 95% confidence interval of the probability
+	ProbabilityLow float64 `json:"probability_ci95_low"`
+
+	// Upper bound of the 95% confidence interval of the probability
+	ProbabilityHigh float64 `json:"probability_ci95_high"`
+}
+
+// NewBatchStatistics aggregates the statistics of a batch of runs
+func NewBatchStatistics(firstSeed int64, cityNames []string, runs []*BatchRun) *BatchStatistics {
+	stats := &BatchStatistics{
+		Runs:               len(runs),
+		FirstSeed:          firstSeed,
+		LastSeed:           firstSeed + int64(len(runs)) - 1,
+		TerminationReasons: make(map[string]int),
+		CitySurvivals:      make([]*CitySurvival, 0, len(cityNames)),
+	}
+	steps := make([]float64, 0, len(runs))
+	destroyedCities := make([]float64, 0, len(runs))
+	survivals := make(map[string]int)
+	for _, run := range runs {
+		stats.TerminationReasons[run.Outcome.Reason.String()]++
+		steps = append(steps, float64(run.Outcome.Steps))
+		destroyedCities = append(destroyedCities, float64(len(run.Report.DestroyedCities)))
+		for _, city := range run.Report.SurvivingCities {
+			survivals[city.Name]++
+		}
+	}
+	stats.Steps = NewSampleStatistics(steps)
+	stats.StepsHistogram = newHistogram(steps, totalHistogramBuckets)
+	stats.DestroyedCities = NewSampleStatistics(destroyedCities)
+	for _, cityName := range cityNames {
+		probability, low, high := wilsonInterval(survivals[cityName], len(runs))
+		stats.CitySurvivals = append(stats.CitySurvivals, &CitySurvival{
+			Name:            cityName,
+			Probability:     probability,
+			ProbabilityLow:  low,
+			ProbabilityHigh: high,
+		})
+	}
+	return stats
+}
+
+// NewSampleStatistics computes the statistics of a sample of values
+func NewSampleStatistics(values []float64) *SampleStatistics {
+	stats := &SampleStatistics{}
+	n := len(values)
+	if n == 0 {
+		return stats
+	}
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	stats.Mean = sum / float64(n)
+	if n > 1 {
+		squares := 0.0
+		for _, value := range sorted {
+			squares += (value - stats.Mean) * (value - stats.Mean)
+		}
+		stats.StdDev = math.Sqrt(squares / float64(n-1))
+	}
+	margin := confidenceZ * stats.StdDev / math.Sqrt(float64(n))
+	stats.MeanLow = stats.Mean - margin
+	stats.MeanHigh = stats.Mean + margin
+	stats.Min = sorted[0]
+	stats.P25 = percentile(sorted, 0.25)
+	stats.P50 = percentile(sorted, 0.50)
+	stats.P75 = percentile(sorted, 0.75)
+	stats.P90 = percentile(sorted, 0.90)
+	stats.Max = sorted[n-1]
+	return stats
+}
+
+// percentile computes a percentile of sorted values with linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// wilsonInterval computes a proportion and its 95% Wilson score confidence interval
+func wilsonInterval(successes, total int) (float64, float64, float64) {
+	if total == 0 {
+		return 0, 0, 0
+	}
+	n := float64(total)
+	p := float64(successes) / n
+	z2 := confidenceZ * confidenceZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := confidenceZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return p, math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// newHistogram computes a histogram of integer values with at most totalBuckets buckets of equal width
+func newHistogram(values []float64, totalBuckets int) []*HistogramBucket {
+	buckets := make([]*HistogramBucket, 0, totalBuckets)
+	if len(values) == 0 {
+		return buckets
+	}
+	minValue, maxValue := values[0], values[0]
+	for _, value := range values {
+		minValue = math.Min(minValue, value)
+		maxValue = math.Max(maxValue, value)
+	}
+	width := uint(math.Ceil((maxValue - minValue + 1) / float64(totalBuckets)))
+	for from := uint(minValue); from <= uint(maxValue); from += width {
+		buckets = append(buckets, &HistogramBucket{
+			From: from,
+			To:   from + width,
+		})
+	}
+	for _, value := range values {
+		buckets[(uint(value)-uint(minValue))/width].Count++
+	}
+	return buckets
+}
+
+// WriteJSON writes the statistics as JSON
+func (s *BatchStatistics) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// WriteText writes the statistics in a human readable format
+func (s *BatchStatistics) WriteText(out io.Writer) error {
+	lines := []string{
+		fmt.Sprintf("Runs: %d (seeds %d to %d)", s.Runs, s.FirstSeed, s.LastSeed),
+		"",
+		"Termination reasons:",
+	}
+	reasons := make([]string, 0, len(s.TerminationReasons))
+	for reason := range s.TerminationReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		total := s.TerminationReasons[reason]
+		lines = append(lines, fmt.Sprintf("  %-22s %6d (%.1f%%)", reason, total, 100*float64(total)/float64(s.Runs)))
+	}
+	lines = append(lines,
+		"",
+		"Steps to termination:",
+		formatSampleStatistics(s.Steps),
+		"  histogram:",
+	)
+	for _, bucket := range s.StepsHistogram {
+		lines = append(lines, fmt.Sprintf("    [%d, %d) %6d", bucket.From, bucket.To, bucket.Count))
+	}
+	lines = append(lines,
+		"",
+		"Destroyed cities:",
+		formatSampleStatistics(s.DestroyedCities),
+		"",
+		"City survival probability:",
+	)
+	for _, citySurvival := range s.CitySurvivals {
+		lines = append(lines, fmt.Sprintf("  %-20s %.3f (95%% CI %.3f - %.3f)",
+			citySurvival.Name, citySurvival.Probability, citySurvival.ProbabilityLow, citySurvival.ProbabilityHigh))
+	}
+	for _, line := range lines {
+		_, err := fmt.Fprintln(out, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSampleStatistics formats sample statistics in a human readable format
+func formatSampleStatistics(s *SampleStatistics) string {
+	return fmt.Sprintf("  mean %.2f (95%% CI %.2f - %.2f), std dev %.2f\n  min %.0f, p25 %.1f, median %.1f, p75 %.1f, p90 %.1f, max %.0f",
+		s.Mean, s.MeanLow, s.MeanHigh, s.StdDev, s.Min, s.P25, s.P50, s.P75, s.P90, s.Max)
+}
@@ -0,0 +1,153 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BatchRunner(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "map.txt"))
+	require.NoError(t, err)
+
+	t.Run("Case 1: OK", func(t *testing.T) {
+		ctx := context.Background()
+
+		runner, err := NewBatchRunner(input, 4, 100, 50, 42, 4)
+		require.NoError(t, err)
+		stats, err := runner.Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 50, stats.Runs)
+		require.Equal(t, int64(42), stats.FirstSeed)
+		require.Equal(t, int64(91), stats.LastSeed)
+		totalReasons := 0
+		for _, total := range stats.TerminationReasons {
+			totalReasons += total
+		}
+		require.Equal(t, 50, totalReasons)
+		require.Len(t, stats.CitySurvivals, 10)
+		require.Equal(t, "Paris", stats.CitySurvivals[0].Name)
+		totalBuckets := 0
+		for _, bucket := range stats.StepsHistogram {
+			totalBuckets += bucket.Count
+		}
+		require.Equal(t, 50, totalBuckets)
+
+		// Results do not depend on the number of workers
+		sequentialRunner, err := NewBatchRunner(input, 4, 100, 50, 42, 1)
+		require.NoError(t, err)
+		statsSequential, err := sequentialRunner.Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, stats, statsSequential)
+
+		out := &bytes.Buffer{}
+		err = stats.WriteText(out)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "Runs: 50 (seeds 42 to 91)")
+		require.Contains(t, out.String(), "City survival probability:")
+
+		out = &bytes.Buffer{}
+		err = stats.WriteJSON(out)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), `"city_survivals"`)
+	})
+
+	t.Run("Case 2: OK with simultaneous step mode", func(t *testing.T) {
+		ctx := context.Background()
+
+		runner, err := NewBatchRunner(input, 4, 100, 50, 42, 4)
+		require.NoError(t, err)
+		runner.SetStepMode(Simultaneous)
+		stats, err := runner.Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 50, stats.Runs)
+
+		sequentialRunner, err := NewBatchRunner(input, 4, 100, 50, 42, 4)
+		require.NoError(t, err)
+		statsSequentialMode, err := sequentialRunner.Run(ctx)
+		require.NoError(t, err)
+		require.NotEqual(t, statsSequentialMode, stats)
+	})
+
+	t.Run("Case 3: OK with seek movement strategy", func(t *testing.T) {
+		ctx := context.Background()
+
+		runner, err := NewBatchRunner(input, 4, 100, 50, 42, 4)
+		require.NoError(t, err)
+		runner.SetMovementStrategy(NewSeekMovement())
+		stats, err := runner.Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 50, stats.Runs)
+
+		uniformRunner, err := NewBatchRunner(input, 4, 100, 50, 42, 4)
+		require.NoError(t, err)
+		statsUniform, err := uniformRunner.Run(ctx)
+		require.NoError(t, err)
+		require.NotEqual(t, statsUniform, stats)
+	})
+
+	t.Run("Case 4: Invalid input", func(t *testing.T) {
+		ctx := context.Background()
+
+		runner, err := NewBatchRunner([]byte("City1 test=City2"), 4, 100, 10, 42, 2)
+		require.NoError(t, err)
+		_, err = runner.Run(ctx)
+		require.ErrorIs(t, err, entity.ErrParseCityDefinition)
+	})
+
+	t.Run("Case 5: No run", func(t *testing.T) {
+		runner, err := NewBatchRunner(input, 4, 100, 0, 42, 2)
+		require.ErrorIs(t, err, entity.ErrInvalidRunCount)
+		require.Nil(t, runner)
+	})
+}
+
+func Test_NewSampleStatistics(t *testing.T) {
+	stats := NewSampleStatistics([]float64{4, 1, 3, 2, 5})
+	require.Equal(t, 3.0, stats.Mean)
+	require.InDelta(t, 1.5811, stats.StdDev, 0.0001)
+	require.InDelta(t, 3.0-1.96*1.5811/2.2361, stats.MeanLow, 0.001)
+	require.InDelta(t, 3.0+1.96*1.5811/2.2361, stats.MeanHigh, 0.001)
+	require.Equal(t, 1.0, stats.Min)
+	require.Equal(t, 2.0, stats.P25)
+	require.Equal(t, 3.0, stats.P50)
+	require.Equal(t, 4.0, stats.P75)
+	require.InDelta(t, 4.6, stats.P90, 0.0001)
+	require.Equal(t, 5.0, stats.Max)
+
+	require.Equal(t, &SampleStatistics{}, NewSampleStatistics([]float64{}))
+}
+
+func Test_wilsonInterval(t *testing.T) {
+	p, low, high := wilsonInterval(5, 10)
+	require.Equal(t, 0.5, p)
+	require.InDelta(t, 0.2366, low, 0.0001)
+	require.InDelta(t, 0.7634, high, 0.0001)
+
+	p, low, high = wilsonInterval(0, 10)
+	require.Equal(t, 0.0, p)
+	require.Equal(t, 0.0, low)
+	require.InDelta(t, 0.2775, high, 0.0001)
+
+	p, low, high = wilsonInterval(0, 0)
+	require.Equal(t, 0.0, p)
+	require.Equal(t, 0.0, low)
+	require.Equal(t, 0.0, high)
+}
+
+func Test_newHistogram(t *testing.T) {
+	buckets := newHistogram([]float64{0, 1, 5, 9, 9, 19}, 4)
+	require.Equal(t, []*HistogramBucket{
+		{From: 0, To: 5, Count: 2},
+		{From: 5, To: 10, Count: 3},
+		{From: 10, To: 15, Count: 0},
+		{From: 15, To: 20, Count: 1},
+	}, buckets)
+
+	require.Equal(t, []*HistogramBucket{{From: 7, To: 8, Count: 3}}, newHistogram([]float64{7, 7, 7}, 4))
+}
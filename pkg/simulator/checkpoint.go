@@ -0,0 +1,322 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// checkpointVersion is the version of the checkpoint format
+const checkpointVersion = 1
+
+// Checkpoint is a snapshot of the complete state of a simulation
+type Checkpoint struct {
+	// Version of the checkpoint format
+	Version int `json:"version"`
+
+	// Number of steps already simulated
+	Step uint `json:"step"`
+
+	// Maximum number of steps to simulate
+	MaxSteps uint `json:"max_steps"`
+
+	// Number of aliens spawned during initialization
+	StartAliens uint `json:"start_aliens"`
+
+	// Semantics used to simulate a step
+	StepMode string `json:"step_mode"`
+
+	// Strategy used to move the aliens
+	Movement string `json:"movement"`
+
+	// Probability to stay in place of the stay movement strategy
+	StayProbability float64 `json:"stay_probability,omitempty"`
+
+	// State of the random generator
+	Random *CheckpointRandom `json:"random"`
+
+	// Alive cities with their remaining links, in their input order
+	Cities []*ReportCity `json:"cities"`
+
+	// All the aliens, ordered by their ids
+	Aliens []*CheckpointAlien `json:"aliens"`
+
+	// Cities destroyed so far
+	DestroyedCities []*ReportDestroyedCity `json:"destroyed_cities"`
+}
+
+// CheckpointRandom is the state of the random generator of a checkpoint
+type CheckpointRandom struct {
+	// Seed of the random generator
+	Seed int64 `json:"seed"`
+
+	// Number of values already drawn from the random generator source
+	Draws uint64 `json:"draws"`
+}
+
+// CheckpointAlien is an alien of a checkpoint
+type CheckpointAlien struct {
+	// Alien identifier
+	AlienID int `json:"id"`
+
+	// City where the alien is
+	City string `json:"city,omitempty"`
+
+	// City where the alien was before
+	PreviousCity string `json:"previous_city,omitempty"`
+
+	// Cities visited by the alien, in alphabetical order
+	VisitedCities []string `json:"visited_cities"`
+
+	// Is the alien trapped
+	Trapped bool `json:"trapped"`
+}
+
+// WriteJSON writes the checkpoint as JSON
+func (c *Checkpoint) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// ReadCheckpoint reads a checkpoint from JSON
+func ReadCheckpoint(in io.Reader) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{}
+	err := json.NewDecoder(in).Decode(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint.Version != checkpointVersion || checkpoint.Random == nil {
+		return nil, entity.ErrUnsupportedCheckpoint
+	}
+	return checkpoint, nil
+}
+
+// WriteCheckpointFile writes a checkpoint to a file
+// The file is replaced atomically so that a previous checkpoint is never left half written
+func WriteCheckpointFile(path string, checkpoint *Checkpoint) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	err = checkpoint.WriteJSON(tmpFile)
+	if err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// Checkpoint takes a snapshot of the complete state of the simulation
+// It requires a seeded random generator so that its state can be restored
+func (s *SimulationEngine) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	log.WithFields(log.Fields{
+		"step": s.totalSteps,
+	}).Debug("Checkpoint")
+
+	random, ok := s.random.(*RandomSeeded)
+	if !ok {
+		return nil, entity.ErrUnsupportedCheckpoint
+	}
+	checkpoint := &Checkpoint{
+		Version:     checkpointVersion,
+		Step:        s.totalSteps,
+		MaxSteps:    s.maxSteps,
+		StartAliens: s.startAliens,
+		StepMode:    s.stepMode.String(),
+		Movement:    s.movement.String(),
+		Random: &CheckpointRandom{
+			Seed:  random.Seed(),
+			Draws: random.Draws(),
+		},
+		Cities:          make([]*ReportCity, 0),
+		Aliens:          make([]*CheckpointAlien, 0),
+		DestroyedCities: append(make([]*ReportDestroyedCity, 0), s.destroyedCities...),
+	}
+	if stayMovement, ok := s.movement.(*StayMovement); ok {
+		checkpoint.StayProbability = stayMovement.StayProbability()
+	}
+
+	// Cities
+	aliveCities, err := s.world.GetAliveCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, city := range aliveCities {
+		checkpoint.Cities = append(checkpoint.Cities, newReportCity(city))
+	}
+
+	// Aliens
+	for i := 0; i < int(s.startAliens); i++ {
+		alien, err := s.world.GetAlien(ctx, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if alien == nil {
+			continue
+		}
+		isTrapped, err := s.world.IsTrappedAlien(ctx, alien)
+		if err != nil {
+			return nil, err
+		}
+		checkpointAlien := &CheckpointAlien{
+			AlienID:       alien.AlienID,
+			VisitedCities: make([]string, 0, len(alien.VisitedCities)),
+			Trapped:       isTrapped,
+		}
+		if alien.City != nil {
+			checkpointAlien.City = alien.City.Name
+		}
+		if alien.PreviousCity != nil {
+			checkpointAlien.PreviousCity = alien.PreviousCity.Name
+		}
+		for cityName := range alien.VisitedCities {
+			checkpointAlien.VisitedCities = append(checkpointAlien.VisitedCities, cityName)
+		}
+		sort.Strings(checkpointAlien.VisitedCities)
+		checkpoint.Aliens = append(checkpoint.Aliens, checkpointAlien)
+	}
+
+	return checkpoint, nil
+}
+
+// NewSimulationEngineFromCheckpoint is a simulation engine constructor that resumes a simulation from a checkpoint
+// The world must be empty, it is loaded with the state of the checkpoint
+// A text observer writing to out is registered, unless out is nil
+func NewSimulationEngineFromCheckpoint(ctx context.Context, checkpoint *Checkpoint, world WorldStorer, out io.Writer) (*SimulationEngine, error) {
+	log.WithFields(log.Fields{
+		"step": checkpoint.Step,
+	}).Info("NewSimulationEngineFromCheckpoint")
+
+	stepMode, err := ParseStepMode(checkpoint.StepMode)
+	if err != nil {
+		return nil, err
+	}
+	movement, err := NewMovementStrategy(checkpoint.Movement, checkpoint.StayProbability)
+	if err != nil {
+		return nil, err
+	}
+	random := RestoreRandomSeeded(checkpoint.Random.Seed, checkpoint.Random.Draws)
+	s := NewSimulationEngine(checkpoint.StartAliens, checkpoint.MaxSteps, world, random, nil, out)
+	s.SetStepMode(stepMode)
+	s.SetMovementStrategy(movement)
+	s.totalSteps = checkpoint.Step
+	s.destroyedCities = append(s.destroyedCities, checkpoint.DestroyedCities...)
+	s.resumed = true
+
+	// Cities and links
+	for _, checkpointCity := range checkpoint.Cities {
+		_, err = world.AddCity(ctx, checkpointCity.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, checkpointCity := range checkpoint.Cities {
+		cityFrom, err := world.GetCity(ctx, checkpointCity.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range checkpointCity.Links {
+			direction, err := entity.ParseDirection(link.Direction)
+			if err != nil {
+				return nil, err
+			}
+			cityTo, err := world.GetCity(ctx, link.City)
+			if err != nil {
+				return nil, err
+			}
+			if cityTo == nil {
+				return nil, entity.ErrUnknownCity
+			}
+			err = world.AddLink(ctx, cityFrom, cityTo, direction)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Aliens
+	for _, checkpointAlien := range checkpoint.Aliens {
+		alien, err := world.AddAlien(ctx, checkpointAlien.AlienID)
+		if err != nil {
+			return nil, err
+		}
+		city, err := world.GetCity(ctx, checkpointAlien.City)
+		if err != nil {
+			return nil, err
+		}
+		if city != nil {
+			err = world.MoveAlien(ctx, alien, city)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if checkpointAlien.Trapped {
+			err = world.TrapAlien(ctx, alien)
+			if err != nil {
+				return nil, err
+			}
+		}
+		alien.PreviousCity, err = world.GetCity(ctx, checkpointAlien.PreviousCity)
+		if err != nil {
+			return nil, err
+		}
+		alien.VisitedCities = make(map[string]bool)
+		for _, cityName := range checkpointAlien.VisitedCities {
+			alien.VisitedCities[cityName] = true
+		}
+	}
+
+	return s, nil
+}
+
+// CheckpointObserver is an observer that writes checkpoints of a simulation to a file
+// A checkpoint is written every interval steps (if interval is not 0) and when the simulation is finalized
+type CheckpointObserver struct {
+	// Simulator to checkpoint
+	simulator Simulator
+
+	// Path of the checkpoint file
+	path string
+
+	// Number of steps between two checkpoints
+	interval uint
+}
+
+var _ Observer = (*CheckpointObserver)(nil)
+
+// NewCheckpointObserver is a checkpoint observer constructor
+func NewCheckpointObserver(simulator Simulator, path string, interval uint) *CheckpointObserver {
+	return &CheckpointObserver{
+		simulator: simulator,
+		path:      path,
+		interval:  interval,
+	}
+}
+
+// Notify notifies the observer of a simulation event
+func (o *CheckpointObserver) Notify(ctx context.Context, event *Event) error {
+	switch {
+	case event.Type == StepEnded && o.interval > 0 && event.Step%o.interval == 0:
+	case event.Type == SimulationFinished:
+	default:
+		return nil
+	}
+	checkpoint, err := o.simulator.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	return WriteCheckpointFile(o.path, checkpoint)
+}
@@ -0,0 +1,201 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_SimulationEngine_CheckpointResume(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "map.txt"))
+	require.NoError(t, err)
+
+	stayMovement, err := NewStayMovement(0.3)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                  string
+		giveAliens, giveSteps uint
+		giveCheckpointSteps   uint
+		giveSeed              int64
+		giveStepMode          StepMode
+		giveMovement          MovementStrategy
+	}{
+		{"Sequential uniform", 4, 200, 1, 42, Sequential, NewUniformMovement()},
+		{"Sequential unvisited", 6, 200, 1, 7, Sequential, NewUnvisitedMovement()},
+		{"Simultaneous lazy", 6, 200, 1, 7, Simultaneous, NewLazyMovement()},
+		{"Sequential stay", 5, 200, 3, 1234, Sequential, stayMovement},
+		{"Simultaneous seek", 4, 200, 2, 42, Simultaneous, NewSeekMovement()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newEngine := func(maxSteps uint, out *bytes.Buffer) *SimulationEngine {
+				s := NewSimulationEngine(tt.giveAliens, maxSteps, NewWorld(), NewRandomSeeded(tt.giveSeed), bytes.NewReader(input), out)
+				s.SetStepMode(tt.giveStepMode)
+				s.SetMovementStrategy(tt.giveMovement)
+				return s
+			}
+
+			// Uninterrupted run
+			reportObserver := NewReportObserver()
+			s := newEngine(tt.giveSteps, &bytes.Buffer{})
+			s.AddObserver(reportObserver)
+			require.NoError(t, s.Run(ctx))
+			wantOutcome, err := s.Outcome(ctx)
+			require.NoError(t, err)
+			wantReport := reportObserver.Report()
+
+			// Interrupted run, checkpointed when finalized
+			checkpointFilepath := filepath.Join(t.TempDir(), "checkpoint.json")
+			s = newEngine(tt.giveCheckpointSteps, &bytes.Buffer{})
+			s.AddObserver(NewCheckpointObserver(s, checkpointFilepath, 0))
+			require.NoError(t, s.Run(ctx))
+
+			// Resumed run
+			checkpointFile, err := os.Open(checkpointFilepath)
+			require.NoError(t, err)
+			defer func() { _ = checkpointFile.Close() }()
+			checkpoint, err := ReadCheckpoint(checkpointFile)
+			require.NoError(t, err)
+			require.Equal(t, tt.giveCheckpointSteps, checkpoint.Step)
+			reportObserver = NewReportObserverFromCheckpoint(checkpoint)
+			s, err = NewSimulationEngineFromCheckpoint(ctx, checkpoint, NewWorld(), &bytes.Buffer{})
+			require.NoError(t, err)
+			s.SetMaxSteps(tt.giveSteps)
+			s.AddObserver(reportObserver)
+			require.NoError(t, s.Run(ctx))
+			gotOutcome, err := s.Outcome(ctx)
+			require.NoError(t, err)
+
+			require.Equal(t, wantOutcome, gotOutcome)
+			require.Equal(t, wantReport, reportObserver.Report())
+		})
+	}
+}
+
+func Test_SimulationEngine_Checkpoint(t *testing.T) {
+	ctx := context.Background()
+
+	input := `
+CityA north=CityB
+CityB south=CityA east=CityC
+CityC west=CityB
+`
+
+	randomerMock := &RandomerMock{}
+	// Alien1 is spawned in CityA, Alien2 in CityB and Alien3 in CityB
+	randomerMock.On("GetRandomInt", 3).Return(0, nil).Once()
+	randomerMock.On("GetRandomInt", 3).Return(1, nil).Times(2)
+	defer randomerMock.AssertExpectations(t)
+
+	s := NewSimulationEngine(3, 10, NewWorld(), randomerMock, strings.NewReader(input), nil)
+	require.NoError(t, s.Prepare(ctx))
+
+	t.Run("Unsupported random generator", func(t *testing.T) {
+		_, err := s.Checkpoint(ctx)
+		require.ErrorIs(t, err, entity.ErrUnsupportedCheckpoint)
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		s.random = NewRandomSeeded(42)
+		checkpoint, err := s.Checkpoint(ctx)
+		require.NoError(t, err)
+
+		out := &bytes.Buffer{}
+		require.NoError(t, checkpoint.WriteJSON(out))
+		require.JSONEq(t, `{
+			"version": 1,
+			"step": 0,
+			"max_steps": 10,
+			"start_aliens": 3,
+			"step_mode": "sequential",
+			"movement": "uniform",
+			"random": {"seed": 42, "draws": 0},
+			"cities": [
+				{"name": "CityA", "links": []},
+				{"name": "CityC", "links": []}
+			],
+			"aliens": [
+				{"id": 1, "city": "CityA", "visited_cities": ["CityA"], "trapped": false},
+				{"id": 2, "city": "CityB", "visited_cities": ["CityB"], "trapped": true},
+				{"id": 3, "city": "CityB", "visited_cities": ["CityB"], "trapped": true}
+			],
+			"destroyed_cities": [
+				{"name": "CityB", "step": 0, "aliens": [2, 3]}
+			]
+		}`, out.String())
+
+		restored, err := ReadCheckpoint(out)
+		require.NoError(t, err)
+		require.Equal(t, checkpoint, restored)
+	})
+}
+
+func Test_ReadCheckpoint(t *testing.T) {
+	tests := []struct {
+		name, giveInput string
+		wantError       error
+	}{
+		{"Unsupported version", `{"version": 2, "random": {"seed": 1}}`, entity.ErrUnsupportedCheckpoint},
+		{"Missing random generator", `{"version": 1}`, entity.ErrUnsupportedCheckpoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCheckpoint(strings.NewReader(tt.giveInput))
+			require.ErrorIs(t, err, tt.wantError)
+		})
+	}
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		_, err := ReadCheckpoint(strings.NewReader("{"))
+		require.Error(t, err)
+	})
+}
+
+func Test_CheckpointObserver(t *testing.T) {
+	ctx := context.Background()
+
+	checkpoint := &Checkpoint{
+		Version: checkpointVersion,
+		Random:  &CheckpointRandom{Seed: 42},
+	}
+
+	tests := []struct {
+		name           string
+		giveEvent      *Event
+		wantCheckpoint bool
+	}{
+		{"Step ended on interval", &Event{Type: StepEnded, Step: 6}, true},
+		{"Step ended out of interval", &Event{Type: StepEnded, Step: 7}, false},
+		{"Step started on interval", &Event{Type: StepStarted, Step: 6}, false},
+		{"Simulation finished", &Event{Type: SimulationFinished, Step: 7}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			simulatorMock := &SimulatorMock{}
+			if tt.wantCheckpoint {
+				simulatorMock.On("Checkpoint", ctx).Return(checkpoint, nil).Once()
+			}
+			defer simulatorMock.AssertExpectations(t)
+
+			checkpointFilepath := filepath.Join(t.TempDir(), "checkpoint.json")
+			o := NewCheckpointObserver(simulatorMock, checkpointFilepath, 3)
+			require.NoError(t, o.Notify(ctx, tt.giveEvent))
+
+			_, err := os.Stat(checkpointFilepath)
+			require.Equal(t, tt.wantCheckpoint, err == nil)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// dotDestroyedCity is a destroyed city drawn by a DOT writer
+type dotDestroyedCity struct {
+	// Destroyed city
+	city *entity.City
+
+	// Links the city had when it was destroyed
+	links []*cityLink
+
+	// Step during which the city was destroyed
+	step uint
+
+	// Aliens that destroyed the city
+	aliens []*entity.Alien
+}
+
+// DotWriter is a writer of the city graph of a world in the Graphviz DOT language
+// The links are labelled with their direction, the destroyed cities are greyed out and the untrapped aliens are annotated in their city
+type DotWriter struct {
+	// Destroyed cities, in their destruction order
+	destroyedCities []*dotDestroyedCity
+}
+
+// NewDotWriter is a DOT writer constructor
+func NewDotWriter() *DotWriter {
+	return &DotWriter{
+		destroyedCities: make([]*dotDestroyedCity, 0),
+	}
+}
+
+// AddDestroyedCity records a city destroyed by aliens so that it is drawn greyed out with its former links
+// The links are copied, as the world removes the links to a destroyed city from the other cities
+func (w *DotWriter) AddDestroyedCity(city *entity.City, step uint, aliens []*entity.Alien) error {
+	links := make([]*cityLink, 0)
+	for _, direction := range city.GetAvailableDirections() {
+		cityTo, err := city.GetCityTo(direction)
+		if err != nil {
+			return err
+		}
+		links = append(links, &cityLink{direction: direction, cityTo: cityTo})
+	}
+	w.destroyedCities = append(w.destroyedCities, &dotDestroyedCity{
+		city:   city,
+		links:  links,
+		step:   step,
+		aliens: aliens,
+	})
+	return nil
+}
+
+// Write writes the city graph of a world as a DOT digraph
+// The alive cities are written in their input order, followed by the destroyed cities in their destruction order
+func (w *DotWriter) Write(ctx context.Context, world WorldStorer, out io.Writer) error {
+	log.Debug("DotWriter Write")
+
+	cities, links, err := aliveCityLinks(ctx, world)
+	if err != nil {
+		return err
+	}
+	lines := []string{
+		"digraph world {",
+		"  node [shape=box];",
+	}
+	for _, city := range cities {
+		label := city.Name
+		aliens, err := world.GetAliensAtCity(ctx, city)
+		if err != nil {
+			return err
+		}
+		if len(aliens) > 0 {
+			label = fmt.Sprintf("%s\n%s", label, formatAliens(aliens))
+		}
+		lines = append(lines, fmt.Sprintf("  %s [label=%s];", dotQuote(city.Name), dotQuote(label)))
+	}
+	for _, destroyedCity := range w.destroyedCities {
+		label := fmt.Sprintf("%s\ndestroyed at step %d", destroyedCity.city.Name, destroyedCity.step)
+		if len(destroyedCity.aliens) > 0 {
+			label = fmt.Sprintf("%s by %s", label, formatAliens(destroyedCity.aliens))
+		}
+		lines = append(lines, fmt.Sprintf("  %s [label=%s, color=grey, fontcolor=grey, style=filled, fillcolor=lightgrey];", dotQuote(destroyedCity.city.Name), dotQuote(label)))
+	}
+	for _, city := range cities {
+		for _, link := range links[city] {
+			lines = append(lines, fmt.Sprintf("  %s -> %s [label=%s];", dotQuote(city.Name), dotQuote(link.cityTo.Name), link.direction))
+		}
+	}
+	for _, destroyedCity := range w.destroyedCities {
+		for _, link := range destroyedCity.links {
+			lines = append(lines, fmt.Sprintf("  %s -> %s [label=%s, color=grey, fontcolor=grey, style=dashed];", dotQuote(destroyedCity.city.Name), dotQuote(link.cityTo.Name), link.direction))
+		}
+	}
+	lines = append(lines, "}")
+
+	_, err = fmt.Fprintln(out, strings.Join(lines, "\n"))
+	return err
+}
+
+// dotQuote quotes a string as a DOT identifier, a new line being written as a centered line break
+func dotQuote(s string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + escaped + `"`
+}
+
+// DotObserver is an observer that writes the city graph of the world in the DOT language when the simulation is finished
+type DotObserver struct {
+	// World of the simulation
+	world WorldStorer
+
+	// DOT writer
+	writer *DotWriter
+
+	// Output writer
+	out io.Writer
+}
+
+var _ Observer = (*DotObserver)(nil)
+
+// NewDotObserver is a DOT observer constructor
+func NewDotObserver(world WorldStorer, out io.Writer) *DotObserver {
+	return &DotObserver{
+		world:  world,
+		writer: NewDotWriter(),
+		out:    out,
+	}
+}
+
+// Notify notifies the observer of a simulation event
+func (o *DotObserver) Notify(ctx context.Context, event *Event) error {
+	switch event.Type {
+	case CityDestroyed:
+		return o.writer.AddDestroyedCity(event.City, event.Step, event.Aliens)
+	case SimulationFinished:
+		return o.writer.Write(ctx, o.world, o.out)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_DotWriter_Write(t *testing.T) {
+	ctx := context.Background()
+
+	world := loadTestWorld(t, `
+Paris north=Brussels west=London
+Brussels south=Paris
+London east=Paris
+"New \"York\""
+`)
+	paris, err := world.GetCity(ctx, "Paris")
+	require.NoError(t, err)
+	london, err := world.GetCity(ctx, "London")
+	require.NoError(t, err)
+	alien1, err := world.AddAlien(ctx, 1)
+	require.NoError(t, err)
+	alien2, err := world.AddAlien(ctx, 2)
+	require.NoError(t, err)
+	alien3, err := world.AddAlien(ctx, 3)
+	require.NoError(t, err)
+	require.NoError(t, world.MoveAlien(ctx, alien1, london))
+	require.NoError(t, world.MoveAlien(ctx, alien2, london))
+	require.NoError(t, world.MoveAlien(ctx, alien3, paris))
+
+	// London is destroyed by aliens 1 and 2
+	writer := NewDotWriter()
+	require.NoError(t, world.TrapAlien(ctx, alien1))
+	require.NoError(t, world.TrapAlien(ctx, alien2))
+	require.NoError(t, world.DestroyCity(ctx, london))
+	require.NoError(t, writer.AddDestroyedCity(london, 2, []*entity.Alien{alien1, alien2}))
+
+	out := &bytes.Buffer{}
+	err = writer.Write(ctx, world, out)
+	require.NoError(t, err)
+	require.Equal(t, `digraph world {
+  node [shape=box];
+  "Paris" [label="Paris\nAlien #3"];
+  "Brussels" [label="Brussels"];
+  "New \"York\"" [label="New \"York\""];
+  "London" [label="London\ndestroyed at step 2 by Alien #1 and Alien #2", color=grey, fontcolor=grey, style=filled, fillcolor=lightgrey];
+  "Paris" -> "Brussels" [label=north];
+  "Brussels" -> "Paris" [label=south];
+  "London" -> "Paris" [label=east, color=grey, fontcolor=grey, style=dashed];
+}
+`, out.String())
+}
+
+func Test_DotObserver(t *testing.T) {
+	ctx := context.Background()
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+	world := NewWorld()
+	out := &bytes.Buffer{}
+	s := NewSimulationEngine(4, 10, world, NewRandomSeeded(42), strings.NewReader(input), nil)
+	report := NewReportObserver()
+	s.AddObserver(report)
+	s.AddObserver(NewDotObserver(world, out))
+	require.NoError(t, s.Run(ctx))
+
+	// Every surviving city is drawn, and every destroyed city is greyed out
+	dot := out.String()
+	require.True(t, strings.HasPrefix(dot, "digraph world {\n"))
+	for _, city := range report.Report().SurvivingCities {
+		require.Contains(t, dot, dotQuote(city.Name)+" [label=")
+	}
+	require.NotEmpty(t, report.Report().DestroyedCities)
+	for _, city := range report.Report().DestroyedCities {
+		require.Contains(t, dot, fmt.Sprintf(`"%s" [label="%s\ndestroyed at step`, city.Name, city.Name))
+	}
+}
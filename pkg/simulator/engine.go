@@ -1,11 +1,8 @@
 package simulator
 
 import (
-	"bufio"
 	"context"
-	"fmt"
 	"io"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -23,8 +20,11 @@ type SimulationEngine struct {
 	// Input reader
 	in io.Reader
 
-	// Output writer
-	out io.Writer
+	// Reader of the world map input
+	mapReader MapReader
+
+	// Observers notified of the simulation events
+	observers []Observer
 
 	// Number of aliens that are spawned during initialization
 	startAliens uint
@@ -34,32 +34,107 @@ type SimulationEngine struct {
 
 	// Number of steps already simulated
 	totalSteps uint
+
+	// Reason why the simulation ended
+	terminationReason TerminationReason
+
+	// Semantics used to simulate a step
+	stepMode StepMode
+
+	// Strategy used to move the aliens
+	movement MovementStrategy
+
+	// Are the links of the input two-way roads
+	twoWayLinks bool
+
+	// What is done when the links of the world are geometrically inconsistent
+	linkCheckMode LinkCheckMode
+
+	// Cities destroyed so far
+	destroyedCities []*ReportDestroyedCity
+
+	// Is the simulation resumed from a checkpoint
+	resumed bool
 }
 
 var _ Simulator = (*SimulationEngine)(nil)
 
 // NewSimulationEngine is a simulation engine constructor
+// A text observer writing to out is registered, unless out is nil
 func NewSimulationEngine(startAliens, maxSteps uint, world WorldStorer, random Randomer, in io.Reader, out io.Writer) *SimulationEngine {
-	return &SimulationEngine{
-		world:       world,
-		random:      random,
-		in:          in,
-		out:         out,
-		maxSteps:    maxSteps,
-		startAliens: startAliens,
+	s := &SimulationEngine{
+		world:           world,
+		random:          random,
+		in:              in,
+		observers:       make([]Observer, 0),
+		maxSteps:        maxSteps,
+		startAliens:     startAliens,
+		mapReader:       NewTextMapReader(""),
+		movement:        NewUniformMovement(),
+		destroyedCities: make([]*ReportDestroyedCity, 0),
+	}
+	if out != nil {
+		s.AddObserver(NewTextObserver(out))
 	}
+	return s
+}
+
+// SetMapReader sets the reader of the world map input (defaults to the text format)
+func (s *SimulationEngine) SetMapReader(mapReader MapReader) {
+	s.mapReader = mapReader
+}
+
+// SetMaxSteps sets the maximum number of steps to simulate
+func (s *SimulationEngine) SetMaxSteps(maxSteps uint) {
+	s.maxSteps = maxSteps
+}
+
+// SetStepMode sets the semantics used to simulate a step (defaults to Sequential)
+func (s *SimulationEngine) SetStepMode(stepMode StepMode) {
+	s.stepMode = stepMode
+}
+
+// SetMovementStrategy sets the strategy used to move the aliens (defaults to uniform)
+func (s *SimulationEngine) SetMovementStrategy(movement MovementStrategy) {
+	s.movement = movement
+}
+
+// SetTwoWayLinks sets whether the links of the input are two-way roads, whose reverse links are added when loaded (defaults to false)
+func (s *SimulationEngine) SetTwoWayLinks(twoWayLinks bool) {
+	s.twoWayLinks = twoWayLinks
+}
+
+// SetLinkCheckMode sets what is done when the links of the world are geometrically inconsistent (defaults to off)
+func (s *SimulationEngine) SetLinkCheckMode(linkCheckMode LinkCheckMode) {
+	s.linkCheckMode = linkCheckMode
+}
+
+// AddObserver registers an observer of the simulation events
+func (s *SimulationEngine) AddObserver(observer Observer) {
+	s.observers = append(s.observers, observer)
 }
 
 // Prepare prepares the simulation
 func (s *SimulationEngine) Prepare(ctx context.Context) error {
 	log.Info("Prepare")
 
+	// A resumed simulation is already prepared
+	if s.resumed {
+		return nil
+	}
+
 	// Read and parse input
 	err := s.loadInputToWorld(ctx)
 	if err != nil {
 		return err
 	}
 
+	// Check the geometry of the links
+	err = s.checkLinks(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Unleash all the aliens
 	for i := 0; i < int(s.startAliens); i++ {
 		// Create alien
@@ -83,12 +158,23 @@ func (s *SimulationEngine) Prepare(ctx context.Context) error {
 			return err
 		}
 		nextCity = aliveCities[r]
-		_, err = s.moveAlienToCity(ctx, alien, nextCity)
+		err = s.notify(ctx, &Event{
+			Type:   AlienSpawned,
+			Step:   s.totalSteps,
+			Aliens: []*entity.Alien{alien},
+			City:   nextCity,
+		})
+		if err != nil {
+			return err
+		}
+		err = s.moveAlienToCity(ctx, alien, nextCity)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+
+	// Aliens spawned in the same city fight
+	return s.resolveFights(ctx)
 }
 
 // HasNextStep computes if a next step of the simulation exists
@@ -99,24 +185,27 @@ func (s *SimulationEngine) HasNextStep(ctx context.Context) (bool, error) {
 
 	// If max steps is reached, there are no more step
 	if s.totalSteps >= s.maxSteps {
+		s.terminationReason = MaxStepsReached
 		return false, nil
 	}
 
-	// If all aliens have been trapped, there are no more step
-	untrappedAliens, err := s.world.GetUntrappedAliens(ctx)
+	// If all cities have been destroyed, there are no more step
+	aliveCities, err := s.world.GetAliveCities(ctx)
 	if err != nil {
 		return false, err
 	}
-	if len(untrappedAliens) == 0 {
+	if len(aliveCities) == 0 {
+		s.terminationReason = AllCitiesDestroyed
 		return false, nil
 	}
 
-	// If all cities have been destroyed, there are no more step
-	aliveCities, err := s.world.GetAliveCities(ctx)
+	// If all aliens have been trapped, there are no more step
+	untrappedAliens, err := s.world.GetUntrappedAliens(ctx)
 	if err != nil {
 		return false, err
 	}
-	if len(aliveCities) == 0 {
+	if len(untrappedAliens) == 0 {
+		s.terminationReason = AllAliensTrapped
 		return false, nil
 	}
 
@@ -131,13 +220,37 @@ func (s *SimulationEngine) SimulateNextStep(ctx context.Context) error {
 
 	// Move randomly each remaining alien
 	s.totalSteps++
+	err := s.notify(ctx, &Event{
+		Type: StepStarted,
+		Step: s.totalSteps,
+	})
+	if err != nil {
+		return err
+	}
+	switch s.stepMode {
+	case Simultaneous:
+		err = s.simulateSimultaneousStep(ctx)
+	default:
+		err = s.simulateSequentialStep(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.notify(ctx, &Event{
+		Type: StepEnded,
+		Step: s.totalSteps,
+	})
+}
+
+// simulateSequentialStep moves the aliens one by one and resolves a fight as soon as an alien arrives in an occupied city
+func (s *SimulationEngine) simulateSequentialStep(ctx context.Context) error {
 	untrappedAliens, err := s.world.GetUntrappedAliens(ctx)
 	if err != nil {
 		return err
 	}
 	for _, alien := range untrappedAliens {
-		// Check if alien is trapped
-		// This occurs if it was trapped previously in this loop
+		// Alien may have been trapped earlier during the step
 		isTrapped, err := s.world.IsTrappedAlien(ctx, alien)
 		if err != nil {
 			return err
@@ -146,33 +259,67 @@ func (s *SimulationEngine) SimulateNextStep(ctx context.Context) error {
 			continue
 		}
 
-		// Move randomly alien to next available city
-		var nextCity *entity.City
-		currentCity := alien.City
-		availableLinks := currentCity.GetAvailableLinks()
-		if len(availableLinks) > 0 {
-			r, err := s.random.GetRandomInt(len(availableLinks))
-			if err != nil {
-				return err
-			}
-			i := 0
-			for _, city := range availableLinks {
-				if i == r {
-					nextCity = city
-					continue
-				}
-				i++
-			}
-			_, err = s.moveAlienToCity(ctx, alien, nextCity)
-			if err != nil {
-				return err
-			}
+		// Move alien to its next city
+		nextCity, err := s.chooseNextCity(ctx, alien)
+		if err != nil {
+			return err
+		}
+		if nextCity == nil {
+			continue
+		}
+		err = s.moveAlienToCity(ctx, alien, nextCity)
+		if err != nil {
+			return err
+		}
+
+		// Alien fights if the city is occupied
+		err = s.resolveFightAtCity(ctx, nextCity)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// simulateSimultaneousStep lets all the aliens choose their destination from the state at the start of the step,
+// then applies all the moves and resolves the fights together
+func (s *SimulationEngine) simulateSimultaneousStep(ctx context.Context) error {
+	untrappedAliens, err := s.world.GetUntrappedAliens(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Choose the destinations
+	nextCities := make([]*entity.City, len(untrappedAliens))
+	for i, alien := range untrappedAliens {
+		nextCities[i], err = s.chooseNextCity(ctx, alien)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Apply the moves
+	for i, alien := range untrappedAliens {
+		if nextCities[i] == nil {
+			continue
+		}
+		err = s.moveAlienToCity(ctx, alien, nextCities[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	// Aliens that landed in the same city fight
+	return s.resolveFights(ctx)
+}
+
+// chooseNextCity chooses the next city of an alien with the movement strategy
+// Returns nil if the alien stays in its city
+func (s *SimulationEngine) chooseNextCity(ctx context.Context, alien *entity.Alien) (*entity.City, error) {
+	return s.movement.NextCity(ctx, alien, s.world, s.random)
+}
+
 // run is a run helper function
 func run(ctx context.Context, s Simulator) error {
 	// Prepare
@@ -185,7 +332,12 @@ func run(ctx context.Context, s Simulator) error {
 	for {
 		select {
 		case <-ctx.Done():
+			// Finalize anyway so that the partial state is reported
 			log.Warn("Simulation was cancelled")
+			err := s.Finalize(ctx)
+			if err != nil {
+				return err
+			}
 			return entity.ErrContextCancelled
 		default:
 			hasNextStep, err := s.HasNextStep(ctx)
@@ -210,148 +362,235 @@ func (s *SimulationEngine) Run(ctx context.Context) error {
 }
 
 // Finalize finalizes the simulation
+// The termination reason is Cancelled if the context is done
 func (s *SimulationEngine) Finalize(ctx context.Context) error {
 	log.WithFields(log.Fields{
 		"steps": s.totalSteps,
 	}).Info("Finalize")
 
+	// A simulation cancelled once it has already ended keeps its termination reason
+	if ctx.Err() != nil && s.terminationReason == NotTerminated {
+		s.terminationReason = Cancelled
+	}
+
 	cities, err := s.world.GetAliveCities(ctx)
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Fprintln(s.out, "")
+	untrappedAliens, err := s.world.GetUntrappedAliens(ctx)
 	if err != nil {
 		return err
 	}
-	for _, city := range cities {
-		_, err = fmt.Fprintln(s.out, city)
+
+	return s.notify(ctx, &Event{
+		Type:   SimulationFinished,
+		Step:   s.totalSteps,
+		Aliens: untrappedAliens,
+		Cities: cities,
+		Reason: s.terminationReason,
+	})
+}
+
+// Outcome retrieves the outcome of the simulation
+// The termination reason is NotTerminated until the simulation has ended
+func (s *SimulationEngine) Outcome(ctx context.Context) (*Outcome, error) {
+	aliveCities, err := s.world.GetAliveCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	untrappedAliens, err := s.world.GetUntrappedAliens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Outcome{
+		Steps:           s.totalSteps,
+		Reason:          s.terminationReason,
+		AliveCities:     len(aliveCities),
+		UntrappedAliens: len(untrappedAliens),
+	}, nil
+}
+
+// notify notifies all the observers of an event
+func (s *SimulationEngine) notify(ctx context.Context, event *Event) error {
+	log.WithFields(log.Fields{
+		"event": event.Type,
+		"step":  event.Step,
+	}).Debug("notify")
+
+	for _, observer := range s.observers {
+		err := observer.Notify(ctx, event)
 		if err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
 
 // loadInputToWorld parses input and saves it to the world
 func (s *SimulationEngine) loadInputToWorld(ctx context.Context) error {
-	// Helper function
-	registerCity := func(cityName string) (*entity.City, error) {
-		city, err := s.world.GetCity(ctx, cityName)
-		if err != nil {
-			return city, err
-		}
-		if city == nil {
-			city, err = s.world.AddCity(ctx, cityName)
-			if err != nil {
-				return city, err
-			}
-		}
-		return city, nil
+	definition, err := s.mapReader.Read(s.in)
+	if err != nil {
+		return err
+	}
+	for _, warning := range definition.Warnings {
+		log.WithFields(log.Fields{
+			"line":   warning.Line,
+			"column": warning.Column,
+		}).Warn(warning.Reason)
 	}
+	err = definition.LoadToWorld(ctx, s.world)
+	if err != nil {
+		return err
+	}
+	if s.twoWayLinks {
+		return definition.AddReverseLinks(ctx, s.world)
+	}
+	return nil
+}
 
-	// Scan input
-	scanner := bufio.NewScanner(s.in)
-	scanner.Split(bufio.ScanLines)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// Skip empty lines
-		if len(line) == 0 {
-			continue
-		}
-		// Assume that a city does not contain any space
-		lineChunks := strings.Split(line, " ")
-		if len(lineChunks) == 0 {
-			return entity.ErrParseCityDefinition
-		}
-		cityFromName := lineChunks[0]
-		cityFrom, err := registerCity(cityFromName)
+// checkLinks checks the geometry of the links of the world according to the link check mode
+func (s *SimulationEngine) checkLinks(ctx context.Context) error {
+	if s.linkCheckMode == LinkCheckOff {
+		return nil
+	}
+	if s.linkCheckMode == LinkCheckRepair {
+		repaired, err := RepairLinks(ctx, s.world)
 		if err != nil {
 			return err
 		}
-		// Parse all direction/city couples
-		for _, lineChunk := range lineChunks[1:] {
-			linkChunks := strings.Split(strings.TrimSpace(lineChunk), "=")
-			if len(linkChunks) != 2 {
-				return entity.ErrParseCityDefinition
-			}
-			directionName := linkChunks[0]
-			cityToName := linkChunks[1]
-			cityTo, err := registerCity(cityToName)
-			if err != nil {
-				return err
-			}
-			var direction entity.Direction
-			switch directionName {
-			case "north":
-				direction = entity.North
-			case "east":
-				direction = entity.East
-			case "south":
-				direction = entity.South
-			case "west":
-				direction = entity.West
-			default:
-				return entity.ErrParseCityDefinition
-			}
-			err = s.world.AddLink(ctx, cityFrom, cityTo, direction)
-			if err != nil {
-				return err
-			}
+		for _, issue := range repaired {
+			log.WithFields(log.Fields{
+				"kind": issue.Kind,
+			}).Warnf("repaired link: %s %s=%s", issue.CityTo, issue.ReverseDirection, issue.CityFrom)
 		}
 	}
-
+	issues, err := CheckLinks(ctx, s.world)
+	if err != nil {
+		return err
+	}
+	if s.linkCheckMode == LinkCheckReject && len(issues) > 0 {
+		return &LinkCheckError{Issues: issues}
+	}
+	for _, issue := range issues {
+		log.WithFields(log.Fields{
+			"kind": issue.Kind,
+		}).Warnf("inconsistent link: %s", issue)
+	}
 	return nil
 }
 
 // moveAlienToCity applies the move of an alien to a city
-func (s *SimulationEngine) moveAlienToCity(ctx context.Context, alien *entity.Alien, city *entity.City) (bool, error) {
+func (s *SimulationEngine) moveAlienToCity(ctx context.Context, alien *entity.Alien, city *entity.City) error {
 	log.WithFields(log.Fields{
 		"alien": alien,
 		"city":  city,
 	}).Debug("moveAlienToCity")
 
-	// Retrieve alien at city
-	destroyedCity := false
-	alienAlreadyInCity, err := s.world.GetAlienAtCity(ctx, city)
+	// Same city, nothing to do
+	cityFrom := alien.City
+	if cityFrom == city {
+		return nil
+	}
+
+	// Record alien current city
+	err := s.world.MoveAlien(ctx, alien, city)
 	if err != nil {
-		return destroyedCity, err
+		return err
 	}
 
-	// Decide what to do next
-	switch {
-	case alienAlreadyInCity == alien:
-		// Same alien, nothing to do
-		return destroyedCity, nil
-	case alienAlreadyInCity == nil:
-		// Record alien current city
-		err := s.world.MoveAlien(ctx, alien, city)
-		if err != nil {
-			return destroyedCity, err
-		}
-	default:
-		// Aliens fight!
-		err = s.world.TrapAlien(ctx, alien)
-		if err != nil {
-			return destroyedCity, err
-		}
-		err = s.world.TrapAlien(ctx, alienAlreadyInCity)
+	return s.notifyAlienMoved(ctx, alien, cityFrom, city)
+}
+
+// resolveFights makes the aliens fight in every city where there are several of them
+func (s *SimulationEngine) resolveFights(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"step": s.totalSteps,
+	}).Debug("resolveFights")
+
+	aliveCities, err := s.world.GetAliveCities(ctx)
+	if err != nil {
+		return err
+	}
+	for _, city := range aliveCities {
+		err = s.resolveFightAtCity(ctx, city)
 		if err != nil {
-			return destroyedCity, err
+			return err
 		}
+	}
+
+	return nil
+}
+
+// resolveFightAtCity makes the aliens fight in a city if there are several of them
+func (s *SimulationEngine) resolveFightAtCity(ctx context.Context, city *entity.City) error {
+	aliensAtCity, err := s.world.GetAliensAtCity(ctx, city)
+	if err != nil {
+		return err
+	}
+	if len(aliensAtCity) < 2 {
+		return nil
+	}
 
-		// Destroy city
-		err = s.world.DestroyCity(ctx, city)
+	return s.destroyCity(ctx, city, aliensAtCity)
+}
+
+// destroyCity traps the fighting aliens and destroys the city where they fought
+func (s *SimulationEngine) destroyCity(ctx context.Context, city *entity.City, fightingAliens []*entity.Alien) error {
+	log.WithFields(log.Fields{
+		"city":   city,
+		"aliens": fightingAliens,
+	}).Debug("destroyCity")
+
+	// Aliens fight!
+	for _, fightingAlien := range fightingAliens {
+		err := s.world.TrapAlien(ctx, fightingAlien)
 		if err != nil {
-			return destroyedCity, err
+			return err
 		}
-		// Print message
-		destroyedCity = true
-		_, err := fmt.Fprintf(s.out, "%s has been destroyed by %s and %s\n", city.Name, alien, alienAlreadyInCity)
+		err = s.notify(ctx, &Event{
+			Type:   AlienTrapped,
+			Step:   s.totalSteps,
+			Aliens: []*entity.Alien{fightingAlien},
+			City:   city,
+		})
 		if err != nil {
-			return destroyedCity, err
+			return err
 		}
 	}
 
-	return destroyedCity, nil
+	// Destroy city
+	err := s.world.DestroyCity(ctx, city)
+	if err != nil {
+		return err
+	}
+	destroyedCity := &ReportDestroyedCity{
+		Name:   city.Name,
+		Step:   s.totalSteps,
+		Aliens: make([]int, 0, len(fightingAliens)),
+	}
+	for _, fightingAlien := range fightingAliens {
+		destroyedCity.Aliens = append(destroyedCity.Aliens, fightingAlien.AlienID)
+	}
+	s.destroyedCities = append(s.destroyedCities, destroyedCity)
+
+	return s.notify(ctx, &Event{
+		Type:   CityDestroyed,
+		Step:   s.totalSteps,
+		Aliens: fightingAliens,
+		City:   city,
+	})
+}
+
+// notifyAlienMoved notifies the move of an alien, unless it has just been spawned
+func (s *SimulationEngine) notifyAlienMoved(ctx context.Context, alien *entity.Alien, cityFrom, cityTo *entity.City) error {
+	if cityFrom == nil {
+		return nil
+	}
+	return s.notify(ctx, &Event{
+		Type:     AlienMoved,
+		Step:     s.totalSteps,
+		Aliens:   []*entity.Alien{alien},
+		City:     cityTo,
+		CityFrom: cityFrom,
+	})
 }
@@ -3,7 +3,10 @@ package simulator
 import (
 	"bytes"
 	"context"
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -12,10 +15,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var update = flag.Bool("update", false, "update golden files")
+
 func Test_SimulationEngine_Prepare(t *testing.T) {
-	var alienNil *entity.Alien
 	alien1 := entity.NewAlien(1)
 	alien2 := entity.NewAlien(2)
+	alien3 := entity.NewAlien(3)
 	city1 := entity.NewCity("City1")
 	city2 := entity.NewCity("City2")
 
@@ -23,22 +28,24 @@ func Test_SimulationEngine_Prepare(t *testing.T) {
 		ctx := context.Background()
 
 		worldStorerMock := &WorldStorerMock{}
-		// Add Alien1 to unoccupied city
-		worldStorerMock.On("AddAlien", ctx, alien1.AlienID).Return(alien1, nil).Once()
-		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
-		worldStorerMock.On("GetAlienAtCity", ctx, city1).Return(alienNil, nil).Once()
-		worldStorerMock.On("MoveAlien", ctx, alien1, city1).Return(nil, nil).Once()
-		// Add Alien2
-		worldStorerMock.On("AddAlien", ctx, alien2.AlienID).Return(alien2, nil).Once()
+		// Spawn Alien1, Alien2 and Alien3 in City1
+		for _, alien := range []*entity.Alien{alien1, alien2, alien3} {
+			worldStorerMock.On("AddAlien", ctx, alien.AlienID).Return(alien, nil).Once()
+			worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
+			worldStorerMock.On("MoveAlien", ctx, alien, city1).Return(nil).Once()
+		}
+		// Aliens fight in City1
 		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
-		worldStorerMock.On("GetAlienAtCity", ctx, city1).Return(alien1, nil).Once()
-		worldStorerMock.On("TrapAlien", ctx, alien1).Return(nil, nil).Once()
-		worldStorerMock.On("TrapAlien", ctx, alien2).Return(nil, nil).Once()
-		worldStorerMock.On("DestroyCity", ctx, city1).Return(nil, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city1).Return([]*entity.Alien{alien1, alien2, alien3}, nil).Once()
+		worldStorerMock.On("TrapAlien", ctx, alien1).Return(nil).Once()
+		worldStorerMock.On("TrapAlien", ctx, alien2).Return(nil).Once()
+		worldStorerMock.On("TrapAlien", ctx, alien3).Return(nil).Once()
+		worldStorerMock.On("DestroyCity", ctx, city1).Return(nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city2).Return([]*entity.Alien{}, nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
-		randomerMock.On("GetRandomInt", mock.Anything).Return(0, nil).Times(2)
+		randomerMock.On("GetRandomInt", mock.Anything).Return(0, nil).Times(3)
 		defer randomerMock.AssertExpectations(t)
 
 		out := &bytes.Buffer{}
@@ -47,15 +54,16 @@ func Test_SimulationEngine_Prepare(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         out,
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{NewTextObserver(out)},
 			totalSteps:  0,
 			maxSteps:    10,
-			startAliens: 2,
+			startAliens: 3,
 		}
 
 		err := s.Prepare(ctx)
 		require.NoError(t, err)
-		require.Equal(t, "City1 has been destroyed by Alien #2 and Alien #1\n", out.String())
+		require.Equal(t, "City1 has been destroyed by Alien #1, Alien #2 and Alien #3\n", out.String())
 	})
 
 	t.Run("Case 2: Error occurs", func(t *testing.T) {
@@ -64,12 +72,11 @@ func Test_SimulationEngine_Prepare(t *testing.T) {
 		error1 := fmt.Errorf("error 1")
 
 		worldStorerMock := &WorldStorerMock{}
-		// Add Alien1 to unoccupied city
+		// Spawn Alien1 in City1
 		worldStorerMock.On("AddAlien", ctx, alien1.AlienID).Return(alien1, nil).Once()
 		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
-		worldStorerMock.On("GetAlienAtCity", ctx, city1).Return(alienNil, nil).Once()
-		worldStorerMock.On("MoveAlien", ctx, alien1, city1).Return(nil, nil).Once()
-		// Add Alien2
+		worldStorerMock.On("MoveAlien", ctx, alien1, city1).Return(nil).Once()
+		// Spawn Alien2
 		worldStorerMock.On("AddAlien", ctx, alien2.AlienID).Return(alien2, nil).Once()
 		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{nil}, error1).Once()
 		defer worldStorerMock.AssertExpectations(t)
@@ -82,7 +89,7 @@ func Test_SimulationEngine_Prepare(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 2,
@@ -117,6 +124,7 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 		wantGetUntrappedAliensCalls  int
 		wantGetAliveCitiesCalls      int
 		wantResult                   bool
+		wantReason                   TerminationReason
 		wantError                    error
 	}{
 		{
@@ -130,6 +138,7 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			wantGetUntrappedAliensCalls: 0,
 			wantGetAliveCitiesCalls:     0,
 			wantResult:                  false,
+			wantReason:                  MaxStepsReached,
 			wantError:                   nil,
 		},
 		{
@@ -141,8 +150,9 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			giveAliveCities:             citiesFilled,
 			giveAliveCitiesError:        nil,
 			wantGetUntrappedAliensCalls: 1,
-			wantGetAliveCitiesCalls:     0,
+			wantGetAliveCitiesCalls:     1,
 			wantResult:                  false,
+			wantReason:                  AllAliensTrapped,
 			wantError:                   nil,
 		},
 		{
@@ -154,8 +164,9 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			giveAliveCities:             citiesFilled,
 			giveAliveCitiesError:        nil,
 			wantGetUntrappedAliensCalls: 1,
-			wantGetAliveCitiesCalls:     0,
+			wantGetAliveCitiesCalls:     1,
 			wantResult:                  false,
+			wantReason:                  NotTerminated,
 			wantError:                   error1,
 		},
 		{
@@ -166,9 +177,24 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			giveUntrappedAliensError:    nil,
 			giveAliveCities:             citiesEmpty,
 			giveAliveCitiesError:        nil,
-			wantGetUntrappedAliensCalls: 1,
+			wantGetUntrappedAliensCalls: 0,
 			wantGetAliveCitiesCalls:     1,
 			wantResult:                  false,
+			wantReason:                  AllCitiesDestroyed,
+			wantError:                   nil,
+		},
+		{
+			testName:                    "All cities destroyed and all aliens trapped",
+			giveTotalSteps:              2,
+			giveMaxSteps:                5,
+			giveUntrappedAliens:         aliensEmpty,
+			giveUntrappedAliensError:    nil,
+			giveAliveCities:             citiesEmpty,
+			giveAliveCitiesError:        nil,
+			wantGetUntrappedAliensCalls: 0,
+			wantGetAliveCitiesCalls:     1,
+			wantResult:                  false,
+			wantReason:                  AllCitiesDestroyed,
 			wantError:                   nil,
 		},
 		{
@@ -179,9 +205,10 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			giveUntrappedAliensError:    nil,
 			giveAliveCities:             citiesEmpty,
 			giveAliveCitiesError:        error2,
-			wantGetUntrappedAliensCalls: 1,
+			wantGetUntrappedAliensCalls: 0,
 			wantGetAliveCitiesCalls:     1,
 			wantResult:                  false,
+			wantReason:                  NotTerminated,
 			wantError:                   error2,
 		},
 		{
@@ -195,6 +222,7 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			wantGetUntrappedAliensCalls: 1,
 			wantGetAliveCitiesCalls:     1,
 			wantResult:                  true,
+			wantReason:                  NotTerminated,
 			wantError:                   nil,
 		},
 	}
@@ -220,7 +248,7 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 				world:       worldStorerMock,
 				random:      randomerMock,
 				in:          &bytes.Buffer{},
-				out:         &bytes.Buffer{},
+				mapReader:   NewTextMapReader(""),
 				totalSteps:  tt.giveTotalSteps,
 				maxSteps:    tt.giveMaxSteps,
 				startAliens: 0,
@@ -229,110 +257,147 @@ func Test_SimulationEngine_HasNextStep(t *testing.T) {
 			result, err := s.HasNextStep(ctx)
 			require.ErrorIs(t, tt.wantError, err)
 			require.Equal(t, tt.wantResult, result)
+			require.Equal(t, tt.wantReason, s.terminationReason)
 		})
 	}
 }
 
 func Test_SimulationEngine_SimulateNextStep(t *testing.T) {
-	var alienNil *entity.Alien
-	alien1 := entity.NewAlien(1)
-	alien2 := entity.NewAlien(2)
-	alien3 := entity.NewAlien(3)
-	city1 := entity.NewCity("City1")
-	city2 := entity.NewCity("City2")
-
-	t.Run("Case 1: Alien1 is trapped / Alien2 is moving to same city", func(t *testing.T) {
+	t.Run("Case 1: Alien1 reaches Alien2 before it moves with sequential step mode", func(t *testing.T) {
 		ctx := context.Background()
 
-		err := city1.SetCityTo(city2, entity.South)
-		require.NoError(t, err)
-		alien2.City = city1
+		alien1 := entity.NewAlien(1)
+		alien2 := entity.NewAlien(2)
+		city1 := entity.NewCity("City1")
+		city2 := entity.NewCity("City2")
+		city1.South = city2
+		city2.North = city1
+		alien1.City = city1
+		alien2.City = city2
 
 		worldStorerMock := &WorldStorerMock{}
 		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{alien1, alien2}, nil).Once()
-		// Alien1 is already trapped
-		worldStorerMock.On("IsTrappedAlien", ctx, alien1).Return(true, nil).Once()
-		// Alien2 is moved to its current city
-		worldStorerMock.On("IsTrappedAlien", ctx, alien2).Return(false, nil).Once()
-		worldStorerMock.On("GetAlienAtCity", ctx, city2).Return(alien2, nil).Once()
+		// Alien1 moves to City2 and fights with Alien2
+		worldStorerMock.On("IsTrappedAlien", ctx, alien1).Return(false, nil).Once()
+		worldStorerMock.On("MoveAlien", ctx, alien1, city2).Return(nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city2).Return([]*entity.Alien{alien2, alien1}, nil).Once()
+		worldStorerMock.On("TrapAlien", ctx, alien2).Return(nil).Once()
+		worldStorerMock.On("TrapAlien", ctx, alien1).Return(nil).Once()
+		worldStorerMock.On("DestroyCity", ctx, city2).Return(nil).Once()
+		// Alien2 is trapped and does not move
+		worldStorerMock.On("IsTrappedAlien", ctx, alien2).Return(true, nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
-		randomerMock.On("GetRandomInt", mock.Anything).Return(0, nil).Once()
+		randomerMock.On("GetRandomInt", 1).Return(0, nil).Once()
 		defer randomerMock.AssertExpectations(t)
 
+		out := &bytes.Buffer{}
+
 		s := SimulationEngine{
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{NewTextObserver(out)},
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
+			movement:    NewUniformMovement(),
+			stepMode:    Sequential,
 		}
 
-		err = s.SimulateNextStep(ctx)
+		err := s.SimulateNextStep(ctx)
 		require.NoError(t, err)
 		require.Equal(t, uint(1), s.totalSteps)
+		require.Equal(t, "City2 has been destroyed by Alien #2 and Alien #1\n", out.String())
 	})
 
-	t.Run("Case 2: Alien1 is trapped / Alien2 is moving to an unoccupied city", func(t *testing.T) {
+	t.Run("Case 2: Alien1 and Alien2 swap their cities with simultaneous step mode", func(t *testing.T) {
 		ctx := context.Background()
 
-		err := city1.SetCityTo(city2, entity.South)
-		require.NoError(t, err)
-		alien2.City = city1
+		alien1 := entity.NewAlien(1)
+		alien2 := entity.NewAlien(2)
+		city1 := entity.NewCity("City1")
+		city2 := entity.NewCity("City2")
+		city1.South = city2
+		city2.North = city1
+		alien1.City = city1
+		alien2.City = city2
 
 		worldStorerMock := &WorldStorerMock{}
 		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{alien1, alien2}, nil).Once()
-		// Alien1 is already trapped
-		worldStorerMock.On("IsTrappedAlien", ctx, alien1).Return(true, nil).Once()
-		// Alien2 is moved to an unoccupied city
-		worldStorerMock.On("IsTrappedAlien", ctx, alien2).Return(false, nil).Once()
-		worldStorerMock.On("GetAlienAtCity", ctx, city2).Return(alienNil, nil).Once()
-		worldStorerMock.On("MoveAlien", ctx, alien2, city2).Return(nil).Once()
+		// Aliens swap their cities
+		worldStorerMock.On("MoveAlien", ctx, alien1, city2).Return(nil).Once()
+		worldStorerMock.On("MoveAlien", ctx, alien2, city1).Return(nil).Once()
+		// No fight
+		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city1).Return([]*entity.Alien{alien2}, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city2).Return([]*entity.Alien{alien1}, nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
-		randomerMock.On("GetRandomInt", mock.Anything).Return(0, nil).Once()
+		randomerMock.On("GetRandomInt", 1).Return(0, nil).Times(2)
 		defer randomerMock.AssertExpectations(t)
 
+		out := &bytes.Buffer{}
+
 		s := SimulationEngine{
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{NewTextObserver(out)},
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
+			movement:    NewUniformMovement(),
+			stepMode:    Simultaneous,
 		}
 
-		err = s.SimulateNextStep(ctx)
+		err := s.SimulateNextStep(ctx)
 		require.NoError(t, err)
 		require.Equal(t, uint(1), s.totalSteps)
+		require.Equal(t, "", out.String())
 	})
 
-	t.Run("Case 3:  Alien1 is trapped / Alien2 is moving to an occupied city", func(t *testing.T) {
+	t.Run("Case 3: Alien1, Alien2 and Alien3 are moving to the same city with simultaneous step mode", func(t *testing.T) {
 		ctx := context.Background()
 
-		err := city1.SetCityTo(city2, entity.South)
-		require.NoError(t, err)
-		alien2.City = city1
+		alien1 := entity.NewAlien(1)
+		alien2 := entity.NewAlien(2)
+		alien3 := entity.NewAlien(3)
+		city1 := entity.NewCity("City1")
+		city2 := entity.NewCity("City2")
+		city3 := entity.NewCity("City3")
+		city4 := entity.NewCity("City4")
+		city1.South = city4
+		city2.West = city4
+		city3.North = city4
+		alien1.City = city1
+		alien2.City = city2
+		alien3.City = city3
 
 		worldStorerMock := &WorldStorerMock{}
-		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{alien1, alien2}, nil).Once()
-		// Alien1 is already trapped
-		worldStorerMock.On("IsTrappedAlien", ctx, alien1).Return(true, nil).Once()
-		// Alien2 is moved to an occupied city
-		worldStorerMock.On("IsTrappedAlien", ctx, alien2).Return(false, nil).Once()
-		worldStorerMock.On("GetAlienAtCity", ctx, city2).Return(alien3, nil).Once()
+		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{alien1, alien2, alien3}, nil).Once()
+		// Aliens move to City4
+		worldStorerMock.On("MoveAlien", ctx, alien1, city4).Return(nil).Once()
+		worldStorerMock.On("MoveAlien", ctx, alien2, city4).Return(nil).Once()
+		worldStorerMock.On("MoveAlien", ctx, alien3, city4).Return(nil).Once()
+		// Aliens fight in City4
+		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2, city3, city4}, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city1).Return([]*entity.Alien{}, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city2).Return([]*entity.Alien{}, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city3).Return([]*entity.Alien{}, nil).Once()
+		worldStorerMock.On("GetAliensAtCity", ctx, city4).Return([]*entity.Alien{alien1, alien2, alien3}, nil).Once()
+		worldStorerMock.On("TrapAlien", ctx, alien1).Return(nil).Once()
 		worldStorerMock.On("TrapAlien", ctx, alien2).Return(nil).Once()
 		worldStorerMock.On("TrapAlien", ctx, alien3).Return(nil).Once()
-		worldStorerMock.On("DestroyCity", ctx, city2).Return(nil).Once()
+		worldStorerMock.On("DestroyCity", ctx, city4).Return(nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
-		randomerMock.On("GetRandomInt", mock.Anything).Return(0, nil).Once()
+		randomerMock.On("GetRandomInt", 1).Return(0, nil).Times(3)
 		defer randomerMock.AssertExpectations(t)
 
 		out := &bytes.Buffer{}
@@ -341,19 +406,22 @@ func Test_SimulationEngine_SimulateNextStep(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         out,
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{NewTextObserver(out)},
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
+			movement:    NewUniformMovement(),
+			stepMode:    Simultaneous,
 		}
 
-		err = s.SimulateNextStep(ctx)
+		err := s.SimulateNextStep(ctx)
 		require.NoError(t, err)
 		require.Equal(t, uint(1), s.totalSteps)
-		require.Equal(t, "City2 has been destroyed by Alien #2 and Alien #3\n", out.String())
+		require.Equal(t, "City4 has been destroyed by Alien #1, Alien #2 and Alien #3\n", out.String())
 	})
 
-	t.Run("Case 2: Error", func(t *testing.T) {
+	t.Run("Case 4: Error", func(t *testing.T) {
 		ctx := context.Background()
 
 		error1 := fmt.Errorf("error 1")
@@ -369,10 +437,11 @@ func Test_SimulationEngine_SimulateNextStep(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
+			movement:    NewUniformMovement(),
 		}
 
 		err := s.SimulateNextStep(ctx)
@@ -452,6 +521,36 @@ func Test_SimulationEngine_Run(t *testing.T) {
 		err := run(ctx, simulatorMock)
 		require.ErrorIs(t, err, error1)
 	})
+
+	t.Run("Case 6: Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		simulatorMock := &SimulatorMock{}
+		simulatorMock.On("Prepare", ctx).Return(nil).Once()
+		simulatorMock.On("HasNextStep", ctx).Return(true, nil).Times(totalSteps)
+		simulatorMock.On("SimulateNextStep", ctx).Return(nil).Times(totalSteps - 1)
+		simulatorMock.On("SimulateNextStep", ctx).Return(nil).Run(func(args mock.Arguments) {
+			cancel()
+		}).Once()
+		simulatorMock.On("Finalize", ctx).Return(nil).Once()
+		defer simulatorMock.AssertExpectations(t)
+
+		err := run(ctx, simulatorMock)
+		require.ErrorIs(t, err, entity.ErrContextCancelled)
+	})
+
+	t.Run("Case 7: Error on Finalize when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		simulatorMock := &SimulatorMock{}
+		simulatorMock.On("Prepare", ctx).Return(nil).Once()
+		simulatorMock.On("Finalize", ctx).Return(error1).Once()
+		defer simulatorMock.AssertExpectations(t)
+
+		err := run(ctx, simulatorMock)
+		require.ErrorIs(t, err, error1)
+	})
 }
 
 func Test_SimulationEngine_Finalize(t *testing.T) {
@@ -463,6 +562,7 @@ func Test_SimulationEngine_Finalize(t *testing.T) {
 
 		worldStorerMock := &WorldStorerMock{}
 		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
+		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{}, nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
@@ -474,7 +574,8 @@ func Test_SimulationEngine_Finalize(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         out,
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{NewTextObserver(out)},
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -503,7 +604,8 @@ func Test_SimulationEngine_Finalize(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          &bytes.Buffer{},
-			out:         out,
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{NewTextObserver(out)},
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -513,6 +615,71 @@ func Test_SimulationEngine_Finalize(t *testing.T) {
 		require.ErrorIs(t, err, error1)
 		require.Equal(t, "", out.String())
 	})
+
+	t.Run("Case 3: Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		worldStorerMock := &WorldStorerMock{}
+		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
+		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{}, nil).Once()
+		defer worldStorerMock.AssertExpectations(t)
+
+		randomerMock := &RandomerMock{}
+		defer randomerMock.AssertExpectations(t)
+
+		recorder := &eventRecorder{}
+
+		s := SimulationEngine{
+			world:       worldStorerMock,
+			random:      randomerMock,
+			in:          &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
+			observers:   []Observer{recorder},
+			totalSteps:  3,
+			maxSteps:    10,
+			startAliens: 0,
+		}
+
+		err := s.Finalize(ctx)
+		require.NoError(t, err)
+		require.Equal(t, Cancelled, s.terminationReason)
+		require.Len(t, recorder.events, 1)
+		require.Equal(t, Cancelled, recorder.events[0].Reason)
+	})
+
+	t.Run("Case 4: Cancelled after the simulation has ended", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		worldStorerMock := &WorldStorerMock{}
+		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{}, nil).Once()
+		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{}, nil).Once()
+		defer worldStorerMock.AssertExpectations(t)
+
+		randomerMock := &RandomerMock{}
+		defer randomerMock.AssertExpectations(t)
+
+		recorder := &eventRecorder{}
+
+		s := SimulationEngine{
+			world:             worldStorerMock,
+			random:            randomerMock,
+			in:                &bytes.Buffer{},
+			mapReader:         NewTextMapReader(""),
+			observers:         []Observer{recorder},
+			totalSteps:        3,
+			maxSteps:          10,
+			startAliens:       0,
+			terminationReason: AllCitiesDestroyed,
+		}
+
+		err := s.Finalize(ctx)
+		require.NoError(t, err)
+		require.Equal(t, AllCitiesDestroyed, s.terminationReason)
+		require.Len(t, recorder.events, 1)
+		require.Equal(t, AllCitiesDestroyed, recorder.events[0].Reason)
+	})
 }
 
 func Test_SimulationEngine_loadInputToWorld(t *testing.T) {
@@ -541,7 +708,7 @@ func Test_SimulationEngine_loadInputToWorld(t *testing.T) {
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          in,
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -615,7 +782,7 @@ City6
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          in,
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -628,13 +795,8 @@ City6
 	t.Run("Case 3: Incorrect direction", func(t *testing.T) {
 		ctx := context.Background()
 
+		// The world is left untouched when the input can't be parsed
 		worldStorerMock := &WorldStorerMock{}
-		// City1
-		worldStorerMock.On("GetCity", ctx, "City1").Return(cityNil, nil).Once()
-		worldStorerMock.On("AddCity", ctx, "City1").Return(city1, nil).Once()
-		// City2
-		worldStorerMock.On("GetCity", ctx, "City2").Return(cityNil, nil).Once()
-		worldStorerMock.On("AddCity", ctx, "City2").Return(city2, nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
@@ -650,7 +812,7 @@ City2 east=City1 south=City4
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          in,
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -658,15 +820,14 @@ City2 east=City1 south=City4
 
 		err := s.loadInputToWorld(ctx)
 		require.ErrorIs(t, err, entity.ErrParseCityDefinition)
+		require.ErrorIs(t, err, entity.ErrUnknownDirection)
 	})
 
 	t.Run("Case 3: Incorrect format", func(t *testing.T) {
 		ctx := context.Background()
 
+		// The world is left untouched when the input can't be parsed
 		worldStorerMock := &WorldStorerMock{}
-		// City1
-		worldStorerMock.On("GetCity", ctx, "City1").Return(cityNil, nil).Once()
-		worldStorerMock.On("AddCity", ctx, "City1").Return(city1, nil).Once()
 		defer worldStorerMock.AssertExpectations(t)
 
 		randomerMock := &RandomerMock{}
@@ -682,7 +843,7 @@ City2 east=City1 south=City4
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          in,
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -719,7 +880,7 @@ City2 east=City1 south=City4
 			world:       worldStorerMock,
 			random:      randomerMock,
 			in:          in,
-			out:         &bytes.Buffer{},
+			mapReader:   NewTextMapReader(""),
 			totalSteps:  0,
 			maxSteps:    10,
 			startAliens: 0,
@@ -729,3 +890,218 @@ City2 east=City1 south=City4
 		require.ErrorIs(t, err, error1)
 	})
 }
+
+func Test_SimulationEngine_Golden(t *testing.T) {
+	tests := []struct {
+		name                  string
+		giveAliens, giveSteps uint
+		giveSeed              int64
+		giveStepMode          StepMode
+		giveMovement          MovementStrategy
+	}{
+		{
+			name:       "few_aliens",
+			giveAliens: 4,
+			giveSteps:  10000,
+			giveSeed:   42,
+		},
+		{
+			name:       "many_aliens",
+			giveAliens: 12,
+			giveSteps:  10000,
+			giveSeed:   1234,
+		},
+		{
+			name:       "few_steps",
+			giveAliens: 6,
+			giveSteps:  2,
+			giveSeed:   7,
+		},
+		{
+			name:         "simultaneous_many_aliens",
+			giveAliens:   12,
+			giveSteps:    10000,
+			giveSeed:     1234,
+			giveStepMode: Simultaneous,
+		},
+		{
+			name:         "simultaneous_few_steps",
+			giveAliens:   6,
+			giveSteps:    2,
+			giveSeed:     7,
+			giveStepMode: Simultaneous,
+		},
+		{
+			name:         "seek_few_aliens",
+			giveAliens:   4,
+			giveSteps:    10000,
+			giveSeed:     42,
+			giveMovement: NewSeekMovement(),
+		},
+		{
+			name:         "lazy_few_aliens",
+			giveAliens:   4,
+			giveSteps:    10000,
+			giveSeed:     42,
+			giveMovement: NewLazyMovement(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			input, err := os.ReadFile(filepath.Join("testdata", "map.txt"))
+			require.NoError(t, err)
+
+			// Run the same simulation twice
+			outputs := make([]string, 0, 2)
+			for i := 0; i < 2; i++ {
+				out := &bytes.Buffer{}
+				s := NewSimulationEngine(tt.giveAliens, tt.giveSteps, NewWorld(), NewRandomSeeded(tt.giveSeed), bytes.NewReader(input), out)
+				s.SetStepMode(tt.giveStepMode)
+				if tt.giveMovement != nil {
+					s.SetMovementStrategy(tt.giveMovement)
+				}
+				err = s.Run(ctx)
+				require.NoError(t, err)
+				outputs = append(outputs, out.String())
+			}
+			require.Equal(t, outputs[0], outputs[1])
+
+			// Compare with golden file
+			goldenFilepath := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				err = os.WriteFile(goldenFilepath, []byte(outputs[0]), 0644)
+				require.NoError(t, err)
+			}
+			golden, err := os.ReadFile(goldenFilepath)
+			require.NoError(t, err)
+			require.Equal(t, string(golden), outputs[0])
+		})
+	}
+}
+
+// eventRecorder is an observer that records the events it is notified of
+type eventRecorder struct {
+	events []*Event
+}
+
+func (r *eventRecorder) Notify(ctx context.Context, event *Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func Test_SimulationEngine_Events(t *testing.T) {
+	ctx := context.Background()
+
+	input := `
+CityA north=CityB
+CityB north=CityC
+CityC south=CityB
+`
+
+	randomerMock := &RandomerMock{}
+	// Alien1 is spawned in CityA, Alien2 in CityC
+	randomerMock.On("GetRandomInt", 3).Return(0, nil).Once()
+	randomerMock.On("GetRandomInt", 3).Return(2, nil).Once()
+	// Both aliens move to CityB
+	randomerMock.On("GetRandomInt", 1).Return(0, nil).Times(2)
+	defer randomerMock.AssertExpectations(t)
+
+	recorder := &eventRecorder{}
+	s := NewSimulationEngine(2, 10, NewWorld(), randomerMock, strings.NewReader(input), nil)
+	s.AddObserver(recorder)
+
+	err := s.Run(ctx)
+	require.NoError(t, err)
+
+	summaries := make([]string, 0, len(recorder.events))
+	for _, event := range recorder.events {
+		summary := fmt.Sprintf("%d %s", event.Step, event.Type)
+		if len(event.Aliens) > 0 {
+			summary = fmt.Sprintf("%s %s", summary, formatAliens(event.Aliens))
+		}
+		if event.CityFrom != nil {
+			summary = fmt.Sprintf("%s from %s", summary, event.CityFrom.Name)
+		}
+		if event.City != nil {
+			summary = fmt.Sprintf("%s at %s", summary, event.City.Name)
+		}
+		for _, city := range event.Cities {
+			summary = fmt.Sprintf("%s [%s]", summary, city)
+		}
+		if event.Reason != NotTerminated {
+			summary = fmt.Sprintf("%s %s", summary, event.Reason)
+		}
+		summaries = append(summaries, summary)
+	}
+	require.Equal(t, []string{
+		"0 AlienSpawned Alien #1 at CityA",
+		"0 AlienSpawned Alien #2 at CityC",
+		"1 StepStarted",
+		"1 AlienMoved Alien #1 from CityA at CityB",
+		"1 AlienMoved Alien #2 from CityC at CityB",
+		"1 AlienTrapped Alien #1 at CityB",
+		"1 AlienTrapped Alien #2 at CityB",
+		"1 CityDestroyed Alien #1 and Alien #2 at CityB",
+		"1 StepEnded",
+		"1 SimulationFinished [CityA] [CityC] all_aliens_trapped",
+	}, summaries)
+}
+
+func Test_SimulationEngine_Outcome(t *testing.T) {
+	alien1 := entity.NewAlien(1)
+	city1 := entity.NewCity("City1")
+	city2 := entity.NewCity("City2")
+
+	t.Run("Case 1: OK", func(t *testing.T) {
+		ctx := context.Background()
+
+		worldStorerMock := &WorldStorerMock{}
+		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{city1, city2}, nil).Once()
+		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{alien1}, nil).Once()
+		defer worldStorerMock.AssertExpectations(t)
+
+		s := SimulationEngine{
+			world:             worldStorerMock,
+			random:            &RandomerMock{},
+			in:                &bytes.Buffer{},
+			mapReader:         NewTextMapReader(""),
+			totalSteps:        10,
+			maxSteps:          10,
+			startAliens:       1,
+			terminationReason: MaxStepsReached,
+		}
+
+		outcome, err := s.Outcome(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &Outcome{
+			Steps:           10,
+			Reason:          MaxStepsReached,
+			AliveCities:     2,
+			UntrappedAliens: 1,
+		}, outcome)
+	})
+
+	t.Run("Case 2: Error", func(t *testing.T) {
+		ctx := context.Background()
+
+		error1 := fmt.Errorf("error 1")
+
+		worldStorerMock := &WorldStorerMock{}
+		worldStorerMock.On("GetAliveCities", ctx).Return([]*entity.City{}, nil).Once()
+		worldStorerMock.On("GetUntrappedAliens", ctx).Return([]*entity.Alien{}, error1).Once()
+		defer worldStorerMock.AssertExpectations(t)
+
+		s := SimulationEngine{
+			world:     worldStorerMock,
+			random:    &RandomerMock{},
+			in:        &bytes.Buffer{},
+			mapReader: NewTextMapReader(""),
+		}
+
+		_, err := s.Outcome(ctx)
+		require.ErrorIs(t, err, error1)
+	})
+}
@@ -9,15 +9,36 @@ type Alien struct {
 
 	// Current city where alien is
 	City *City
+
+	// Previous city where alien was
+	PreviousCity *City
+
+	// Names of the cities visited by the alien
+	VisitedCities map[string]bool
 }
 
 // NewAlien is an alien constructor
 func NewAlien(alienID int) *Alien {
 	return &Alien{
-		AlienID: alienID,
+		AlienID:       alienID,
+		VisitedCities: make(map[string]bool),
 	}
 }
 
+// MoveTo moves the alien to a city and keeps track of its previous and visited cities
+func (a *Alien) MoveTo(city *City) {
+	a.PreviousCity = a.City
+	a.City = city
+	if city != nil {
+		a.VisitedCities[city.Name] = true
+	}
+}
+
+// HasVisited checks if the alien has already visited a city
+func (a *Alien) HasVisited(city *City) bool {
+	return city != nil && a.VisitedCities[city.Name]
+}
+
 // String implements Stringer interface for an alien
 func (a *Alien) String() string {
 	return fmt.Sprintf("Alien #%d", a.AlienID)
@@ -11,3 +11,22 @@ func Test_NewAlien(t *testing.T) {
 	require.Equal(t, 123, a.AlienID)
 	require.Equal(t, "Alien #123", a.String())
 }
+
+func Test_Alien_MoveTo(t *testing.T) {
+	city1 := NewCity("City1")
+	city2 := NewCity("City2")
+	a := NewAlien(1)
+	require.False(t, a.HasVisited(city1))
+
+	a.MoveTo(city1)
+	require.Equal(t, city1, a.City)
+	require.Nil(t, a.PreviousCity)
+	require.True(t, a.HasVisited(city1))
+	require.False(t, a.HasVisited(city2))
+
+	a.MoveTo(city2)
+	require.Equal(t, city2, a.City)
+	require.Equal(t, city1, a.PreviousCity)
+	require.True(t, a.HasVisited(city1))
+	require.True(t, a.HasVisited(city2))
+}
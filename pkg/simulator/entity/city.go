@@ -3,6 +3,7 @@ package entity
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -116,20 +117,68 @@ func (c *City) GetAvailableLinks() map[Direction]*City {
 	return links
 }
 
+// GetAvailableDirections retrieves the directions of the available links from this city
+// The directions are returned in the canonical order: North, East, South, West
+func (c *City) GetAvailableDirections() []Direction {
+	directions := make([]Direction, 0, len(Directions))
+	for _, direction := range Directions {
+		cityTo, err := c.GetCityTo(direction)
+		if err == nil && cityTo != nil {
+			directions = append(directions, direction)
+		}
+	}
+	return directions
+}
+
 // String implementats Stringer interface for a city
 func (c *City) String() string {
-	chunks := []string{c.Name}
+	chunks := []string{QuoteCityName(c.Name)}
 	if c.North != nil {
-		chunks = append(chunks, fmt.Sprintf("north=%s", c.North.Name))
+		chunks = append(chunks, fmt.Sprintf("north=%s", QuoteCityName(c.North.Name)))
 	}
 	if c.East != nil {
-		chunks = append(chunks, fmt.Sprintf("east=%s", c.East.Name))
+		chunks = append(chunks, fmt.Sprintf("east=%s", QuoteCityName(c.East.Name)))
 	}
 	if c.South != nil {
-		chunks = append(chunks, fmt.Sprintf("south=%s", c.South.Name))
+		chunks = append(chunks, fmt.Sprintf("south=%s", QuoteCityName(c.South.Name)))
 	}
 	if c.West != nil {
-		chunks = append(chunks, fmt.Sprintf("west=%s", c.West.Name))
+		chunks = append(chunks, fmt.Sprintf("west=%s", QuoteCityName(c.West.Name)))
 	}
 	return strings.Join(chunks, " ")
 }
+
+// QuoteCityName quotes a city name so that it can be read back from the text map format
+// The name is left untouched unless it is empty or contains a whitespace, a control character, a double quote or an equal sign
+// A quoted name has its backslashes and double quotes escaped with a backslash, and its control characters escaped as \n, \r, \t or \uXXXX
+func QuoteCityName(name string) string {
+	if name != "" && !strings.ContainsAny(name, "\"=") && strings.IndexFunc(name, isSpaceOrControl) < 0 {
+		return name
+	}
+	var quoted strings.Builder
+	quoted.WriteRune('"')
+	for _, r := range name {
+		switch {
+		case r == '\\' || r == '"':
+			quoted.WriteRune('\\')
+			quoted.WriteRune(r)
+		case r == '\n':
+			quoted.WriteString(`\n`)
+		case r == '\r':
+			quoted.WriteString(`\r`)
+		case r == '\t':
+			quoted.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&quoted, `\u%04x`, r)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteRune('"')
+	return quoted.String()
+}
+
+// isSpaceOrControl checks if a character is a whitespace or a control character
+func isSpaceOrControl(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
@@ -17,6 +17,7 @@ func Test_City_NewCity(t *testing.T) {
 		{"City3", &City{Name: "CityN"}, &City{Name: "CityE"}, &City{Name: "CityS"}, nil, "City3 north=CityN east=CityE south=CityS"},
 		{"City4", &City{Name: "CityN"}, &City{Name: "CityE"}, &City{Name: "CityS"}, &City{Name: "CityW"}, "City4 north=CityN east=CityE south=CityS west=CityW"},
 		{"City5", nil, &City{Name: "CityE"}, nil, &City{Name: "CityW"}, "City5 east=CityE west=CityW"},
+		{"New York", &City{Name: "Rio de Janeiro"}, nil, nil, nil, `"New York" north="Rio de Janeiro"`},
 	}
 
 	for _, tt := range tests {
@@ -114,3 +115,51 @@ func Test_City_Flow(t *testing.T) {
 		})
 	}
 }
+
+func Test_City_GetAvailableDirections(t *testing.T) {
+	tests := []struct {
+		name                                     string
+		cityNorth, cityEast, citySouth, cityWest *City
+		want                                     []Direction
+	}{
+		{"No link", nil, nil, nil, nil, []Direction{}},
+		{"All links", &City{Name: "CityN"}, &City{Name: "CityE"}, &City{Name: "CityS"}, &City{Name: "CityW"}, []Direction{North, East, South, West}},
+		{"West and North", &City{Name: "CityN"}, nil, nil, &City{Name: "CityW"}, []Direction{North, West}},
+		{"South and East", nil, &City{Name: "CityE"}, &City{Name: "CityS"}, nil, []Direction{East, South}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCity("City")
+			c.North = tt.cityNorth
+			c.East = tt.cityEast
+			c.South = tt.citySouth
+			c.West = tt.cityWest
+			require.Equal(t, tt.want, c.GetAvailableDirections())
+		})
+	}
+}
+
+func Test_QuoteCityName(t *testing.T) {
+	tests := []struct {
+		giveName string
+		want     string
+	}{
+		{"Paris", "Paris"},
+		{`Back\slash`, `Back\slash`},
+		{"New York", `"New York"`},
+		{"Tab\tCity", `"Tab\tCity"`},
+		{"New\nYork", `"New\nYork"`},
+		{"Carriage\rReturn", `"Carriage\rReturn"`},
+		{"Bell\a", `"Bell\u0007"`},
+		{"A=B", `"A=B"`},
+		{`The "Big" \ Apple`, `"The \"Big\" \\ Apple"`},
+		{"", `""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.giveName, func(t *testing.T) {
+			require.Equal(t, tt.want, QuoteCityName(tt.giveName))
+		})
+	}
+}
@@ -13,3 +13,48 @@ const (
 	// West direction
 	West
 )
+
+// Directions lists all the directions in their canonical order: North, East, South, West
+var Directions = []Direction{North, East, South, West}
+
+// String implements Stringer interface for a direction
+func (d Direction) String() string {
+	switch d {
+	case North:
+		return "north"
+	case East:
+		return "east"
+	case South:
+		return "south"
+	case West:
+		return "west"
+	default:
+		return "unknown"
+	}
+}
+
+// Opposite retrieves the opposite direction
+func (d Direction) Opposite() Direction {
+	switch d {
+	case North:
+		return South
+	case East:
+		return West
+	case South:
+		return North
+	case West:
+		return East
+	default:
+		return d
+	}
+}
+
+// ParseDirection parses a direction from its name
+func ParseDirection(name string) (Direction, error) {
+	for _, direction := range Directions {
+		if direction.String() == name {
+			return direction, nil
+		}
+	}
+	return Direction(0), ErrUnknownDirection
+}
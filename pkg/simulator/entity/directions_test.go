@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseDirection(t *testing.T) {
+	tests := []struct {
+		giveName      string
+		wantDirection Direction
+		wantError     error
+	}{
+		{"north", North, nil},
+		{"east", East, nil},
+		{"south", South, nil},
+		{"west", West, nil},
+		{"up", Direction(0), ErrUnknownDirection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.giveName, func(t *testing.T) {
+			direction, err := ParseDirection(tt.giveName)
+			require.Equal(t, tt.wantError, err)
+			require.Equal(t, tt.wantDirection, direction)
+		})
+	}
+}
+
+func Test_Direction_Opposite(t *testing.T) {
+	tests := []struct {
+		giveDirection Direction
+		wantDirection Direction
+	}{
+		{North, South},
+		{East, West},
+		{South, North},
+		{West, East},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.giveDirection.String(), func(t *testing.T) {
+			require.Equal(t, tt.wantDirection, tt.giveDirection.Opposite())
+		})
+	}
+}
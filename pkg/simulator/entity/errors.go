@@ -41,6 +41,48 @@ var (
 	// ErrRandomOutOfBounds is trigerred when the random number generation is not possible
 	ErrRandomOutOfBounds error = fmt.Errorf("random input out of bounds")
 
+	// ErrUnknownOutputFormat is triggered when an unknown output format is provided
+	ErrUnknownOutputFormat error = fmt.Errorf("unknown output format provided")
+
+	// ErrUnknownReportFormat is triggered when an unknown report format is provided
+	ErrUnknownReportFormat error = fmt.Errorf("unknown report format provided")
+
+	// ErrUnknownStepMode is triggered when an unknown step mode is provided
+	ErrUnknownStepMode error = fmt.Errorf("unknown step mode provided")
+
+	// ErrUnknownMovementStrategy is triggered when an unknown movement strategy is provided
+	ErrUnknownMovementStrategy error = fmt.Errorf("unknown movement strategy provided")
+
+	// ErrInvalidProbability is triggered when a probability is not between 0 and 1
+	ErrInvalidProbability error = fmt.Errorf("probability must be between 0 and 1")
+
+	// ErrUnsupportedCheckpoint is triggered when a checkpoint can't be taken or restored
+	ErrUnsupportedCheckpoint error = fmt.Errorf("unsupported checkpoint")
+
+	// ErrUnknownLinkCheckMode is triggered when an unknown link check mode is provided
+	ErrUnknownLinkCheckMode error = fmt.Errorf("unknown link check mode provided")
+
+	// ErrInconsistentLinks is triggered when the links of the world are geometrically inconsistent
+	ErrInconsistentLinks error = fmt.Errorf("links are geometrically inconsistent")
+
+	// ErrReverseLinkConflict is triggered when the reverse link of a two-way link conflicts with an existing link
+	ErrReverseLinkConflict error = fmt.Errorf("the reverse link conflicts with an existing link")
+
+	// ErrUnknownMapFormat is triggered when an unknown world map format is provided
+	ErrUnknownMapFormat error = fmt.Errorf("unknown map format provided")
+
+	// ErrUnknownMapShape is triggered when an unknown map shape is provided
+	ErrUnknownMapShape error = fmt.Errorf("unknown map shape provided")
+
+	// ErrInvalidMapSize is triggered when a generated map would have no city
+	ErrInvalidMapSize error = fmt.Errorf("map size must be positive")
+
+	// ErrUnknownRenderMode is triggered when an unknown render mode is provided
+	ErrUnknownRenderMode error = fmt.Errorf("unknown render mode provided")
+
+	// ErrInvalidRunCount is triggered when a batch would have no run
+	ErrInvalidRunCount error = fmt.Errorf("run count must be a positive integer")
+
 	// ErrContextCancelled is trigerred when the context is cancelled
 	ErrContextCancelled error = fmt.Errorf("the context was cancelled")
 )
@@ -0,0 +1,77 @@
+package simulator
+
+import (
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// EventType represents the type of a simulation event
+type EventType int
+
+const (
+	// AlienSpawned event is triggered when an alien is spawned in a city
+	AlienSpawned EventType = iota + 1
+
+	// AlienMoved event is triggered when an alien moves from a city to another city
+	AlienMoved
+
+	// AlienTrapped event is triggered when an alien gets trapped in a city
+	AlienTrapped
+
+	// CityDestroyed event is triggered when a city is destroyed by aliens
+	CityDestroyed
+
+	// StepStarted event is triggered when a simulation step starts
+	StepStarted
+
+	// StepEnded event is triggered when a simulation step ends
+	StepEnded
+
+	// SimulationFinished event is triggered when the simulation is finalized
+	SimulationFinished
+)
+
+// String implements Stringer interface for an event type
+func (et EventType) String() string {
+	switch et {
+	case AlienSpawned:
+		return "AlienSpawned"
+	case AlienMoved:
+		return "AlienMoved"
+	case AlienTrapped:
+		return "AlienTrapped"
+	case CityDestroyed:
+		return "CityDestroyed"
+	case StepStarted:
+		return "StepStarted"
+	case StepEnded:
+		return "StepEnded"
+	case SimulationFinished:
+		return "SimulationFinished"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event represents an event that occurred during a simulation
+type Event struct {
+	// Type of the event
+	Type EventType
+
+	// Step during which the event occurred (0 during preparation)
+	Step uint
+
+	// Aliens involved in the event (untrapped aliens at the end of the simulation)
+	Aliens []*entity.Alien
+
+	// City where the event occurred (destination city of a move)
+	City *entity.City
+
+	// Origin city of a move
+	CityFrom *entity.City
+
+	// Alive cities at the end of the simulation
+	Cities []*entity.City
+
+	// Reason why the simulation ended
+	Reason TerminationReason
+}
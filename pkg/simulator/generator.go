@@ -0,0 +1,221 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// MapShape represents the shape of a generated world map
+type MapShape int
+
+const (
+	// GridShape map shape is a rectangular grid where every city is linked to its neighbours
+	GridShape MapShape = iota
+
+	// SparseGridShape map shape is a rectangular grid where the roads are randomly removed
+	SparseGridShape
+
+	// RandomShape map shape is a random planar-ish graph, where the cities are randomly placed on a grid and linked to their nearest neighbours
+	RandomShape
+
+	// IslandsShape map shape is made of disconnected random planar-ish graphs
+	IslandsShape
+)
+
+// String implements Stringer interface for a map shape
+func (s MapShape) String() string {
+	switch s {
+	case GridShape:
+		return "grid"
+	case SparseGridShape:
+		return "sparse-grid"
+	case RandomShape:
+		return "random"
+	case IslandsShape:
+		return "islands"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMapShape parses a map shape from its name
+func ParseMapShape(name string) (MapShape, error) {
+	for _, shape := range []MapShape{GridShape, SparseGridShape, RandomShape, IslandsShape} {
+		if shape.String() == name {
+			return shape, nil
+		}
+	}
+	return GridShape, entity.ErrUnknownMapShape
+}
+
+const (
+	// defaultMapDensity is the default density of a generated map
+	defaultMapDensity = 0.7
+
+	// defaultMapIslands is the default number of islands of a generated map
+	defaultMapIslands = 3
+)
+
+// MapGenerator is a random world map generator
+// The cities are laid out on a grid and only linked along its rows and columns, so that the links are two-way and geometrically consistent
+type MapGenerator struct {
+	// Shape of the map
+	shape MapShape
+
+	// Size of the grid (of each island for the islands shape)
+	width, height uint
+
+	// Probability that a road is kept (sparse grid shape), or that a grid position holds a city (random and islands shapes)
+	density float64
+
+	// Number of islands (islands shape)
+	islands uint
+
+	// Random generator
+	random Randomer
+}
+
+// NewMapGenerator is a map generator constructor
+func NewMapGenerator(shape MapShape, width, height uint, random Randomer) (*MapGenerator, error) {
+	if width == 0 || height == 0 {
+		return nil, entity.ErrInvalidMapSize
+	}
+	return &MapGenerator{
+		shape:   shape,
+		width:   width,
+		height:  height,
+		density: defaultMapDensity,
+		islands: defaultMapIslands,
+		random:  random,
+	}, nil
+}
+
+// SetDensity sets the density of the map
+func (g *MapGenerator) SetDensity(density float64) error {
+	if density < 0 || density > 1 {
+		return entity.ErrInvalidProbability
+	}
+	g.density = density
+	return nil
+}
+
+// SetIslands sets the number of islands of the map
+func (g *MapGenerator) SetIslands(islands uint) error {
+	if islands == 0 {
+		return entity.ErrInvalidMapSize
+	}
+	g.islands = islands
+	return nil
+}
+
+// Generate generates the cities and links of the map in a world
+// The cities are named after their row and column on the grid, e.g. City-2-3 (Island1-City-2-3 for the islands shape)
+func (g *MapGenerator) Generate(ctx context.Context, world WorldStorer) error {
+	log.WithFields(log.Fields{
+		"shape":   g.shape,
+		"width":   g.width,
+		"height":  g.height,
+		"density": g.density,
+		"islands": g.islands,
+	}).Info("Generate")
+
+	switch g.shape {
+	case GridShape:
+		return g.generateGrid(ctx, world, "", 1, 1)
+	case SparseGridShape:
+		return g.generateGrid(ctx, world, "", 1, g.density)
+	case RandomShape:
+		return g.generateGrid(ctx, world, "", g.density, 1)
+	case IslandsShape:
+		for island := uint(1); island <= g.islands; island++ {
+			err := g.generateGrid(ctx, world, fmt.Sprintf("Island%d-", island), g.density, 1)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return entity.ErrUnknownMapShape
+	}
+}
+
+// generateGrid generates cities on the positions of a grid given their probability, and links every city to its nearest neighbours on its row and column given the probability of a road
+func (g *MapGenerator) generateGrid(ctx context.Context, world WorldStorer, prefix string, cityProbability, roadProbability float64) error {
+	grid := make([][]*entity.City, g.height)
+	for row := range grid {
+		grid[row] = make([]*entity.City, g.width)
+		for column := range grid[row] {
+			draw, err := g.draw(cityProbability)
+			if err != nil {
+				return err
+			}
+			if !draw {
+				continue
+			}
+			grid[row][column], err = world.AddCity(ctx, fmt.Sprintf("%sCity-%d-%d", prefix, row+1, column+1))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Link the cities along the rows, then along the columns
+	for row := uint(0); row < g.height; row++ {
+		var cityWest *entity.City
+		for column := uint(0); column < g.width; column++ {
+			err := g.linkNearest(ctx, world, cityWest, grid[row][column], entity.East, roadProbability)
+			if err != nil {
+				return err
+			}
+			if grid[row][column] != nil {
+				cityWest = grid[row][column]
+			}
+		}
+	}
+	for column := uint(0); column < g.width; column++ {
+		var cityNorth *entity.City
+		for row := uint(0); row < g.height; row++ {
+			err := g.linkNearest(ctx, world, cityNorth, grid[row][column], entity.South, roadProbability)
+			if err != nil {
+				return err
+			}
+			if grid[row][column] != nil {
+				cityNorth = grid[row][column]
+			}
+		}
+	}
+	return nil
+}
+
+// linkNearest links two neighbour cities with a two-way road given its probability
+func (g *MapGenerator) linkNearest(ctx context.Context, world WorldStorer, cityFrom, cityTo *entity.City, direction entity.Direction, roadProbability float64) error {
+	if cityFrom == nil || cityTo == nil {
+		return nil
+	}
+	draw, err := g.draw(roadProbability)
+	if err != nil || !draw {
+		return err
+	}
+	err = world.AddLink(ctx, cityFrom, cityTo, direction)
+	if err != nil {
+		return err
+	}
+	return world.AddLink(ctx, cityTo, cityFrom, direction.Opposite())
+}
+
+// draw draws a random event given its probability
+// Nothing is drawn from the random generator if the event is certain
+func (g *MapGenerator) draw(probability float64) (bool, error) {
+	if probability >= 1 {
+		return true, nil
+	}
+	r, err := g.random.GetRandomInt(probabilityResolution)
+	if err != nil {
+		return false, err
+	}
+	return r < int(probability*probabilityResolution), nil
+}
@@ -0,0 +1,154 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_ParseMapShape(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveName  string
+		wantShape MapShape
+		wantError error
+	}{
+		{"Grid", "grid", GridShape, nil},
+		{"Sparse grid", "sparse-grid", SparseGridShape, nil},
+		{"Random", "random", RandomShape, nil},
+		{"Islands", "islands", IslandsShape, nil},
+		{"Unknown", "torus", GridShape, entity.ErrUnknownMapShape},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := ParseMapShape(tt.giveName)
+			require.Equal(t, tt.wantError, err)
+			require.Equal(t, tt.wantShape, shape)
+		})
+	}
+}
+
+func Test_NewMapGenerator(t *testing.T) {
+	_, err := NewMapGenerator(GridShape, 0, 3, NewRandomSeeded(42))
+	require.Equal(t, entity.ErrInvalidMapSize, err)
+	_, err = NewMapGenerator(GridShape, 3, 0, NewRandomSeeded(42))
+	require.Equal(t, entity.ErrInvalidMapSize, err)
+
+	generator, err := NewMapGenerator(IslandsShape, 3, 3, NewRandomSeeded(42))
+	require.NoError(t, err)
+	require.Equal(t, entity.ErrInvalidProbability, generator.SetDensity(1.5))
+	require.Equal(t, entity.ErrInvalidProbability, generator.SetDensity(-0.1))
+	require.Equal(t, entity.ErrInvalidMapSize, generator.SetIslands(0))
+}
+
+func Test_MapGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name        string
+		giveShape   MapShape
+		giveDensity float64
+		want        string
+	}{
+		{
+			name:        "Grid",
+			giveShape:   GridShape,
+			giveDensity: 0,
+			want: `City-1-1 east=City-1-2 south=City-2-1
+City-1-2 east=City-1-3 south=City-2-2 west=City-1-1
+City-1-3 south=City-2-3 west=City-1-2
+City-2-1 north=City-1-1 east=City-2-2
+City-2-2 north=City-1-2 east=City-2-3 west=City-2-1
+City-2-3 north=City-1-3 west=City-2-2
+`,
+		},
+		{
+			name:        "Sparse grid without roads",
+			giveShape:   SparseGridShape,
+			giveDensity: 0,
+			want: `City-1-1
+City-1-2
+City-1-3
+City-2-1
+City-2-2
+City-2-3
+`,
+		},
+		{
+			name:        "Random without cities",
+			giveShape:   RandomShape,
+			giveDensity: 0,
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			generator, err := NewMapGenerator(tt.giveShape, 3, 2, NewRandomSeeded(42))
+			require.NoError(t, err)
+			require.NoError(t, generator.SetDensity(tt.giveDensity))
+			world := NewWorld()
+			require.NoError(t, generator.Generate(ctx, world))
+			out := &bytes.Buffer{}
+			require.NoError(t, NewTextMapWriter().Write(ctx, world, out))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func Test_MapGenerator_Generate_Valid(t *testing.T) {
+	for _, shape := range []MapShape{GridShape, SparseGridShape, RandomShape, IslandsShape} {
+		for seed := int64(0); seed < 10; seed++ {
+			ctx := context.Background()
+			generator, err := NewMapGenerator(shape, 8, 5, NewRandomSeeded(seed))
+			require.NoError(t, err)
+			require.NoError(t, generator.SetDensity(0.6))
+			world := NewWorld()
+			require.NoError(t, generator.Generate(ctx, world))
+
+			// The links are geometrically consistent
+			issues, err := CheckLinks(ctx, world)
+			require.NoError(t, err)
+			require.Empty(t, issues, shape.String())
+
+			// The generated map can be read back
+			out := &bytes.Buffer{}
+			require.NoError(t, NewTextMapWriter().Write(ctx, world, out))
+			reader := NewTextMapReader("")
+			reader.SetCollectAll(true)
+			definition, err := reader.Read(out)
+			require.NoError(t, err, shape.String())
+
+			// The islands are disconnected
+			if shape == IslandsShape {
+				for _, cityDefinition := range definition.Cities {
+					island := strings.SplitN(cityDefinition.Name, "-", 2)[0]
+					for _, linkDefinition := range cityDefinition.Links {
+						require.True(t, strings.HasPrefix(linkDefinition.CityTo, island+"-"))
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_MapGenerator_Generate_Seed(t *testing.T) {
+	generate := func(seed int64) string {
+		ctx := context.Background()
+		generator, err := NewMapGenerator(RandomShape, 10, 10, NewRandomSeeded(seed))
+		require.NoError(t, err)
+		world := NewWorld()
+		require.NoError(t, generator.Generate(ctx, world))
+		out := &bytes.Buffer{}
+		require.NoError(t, NewTextMapWriter().Write(ctx, world, out))
+		return out.String()
+	}
+
+	require.Equal(t, generate(42), generate(42))
+	require.NotEqual(t, generate(42), generate(43))
+}
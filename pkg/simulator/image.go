@@ -0,0 +1,311 @@
+package simulator
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+const (
+	// imageCellSize is the size in pixels of a grid position
+	imageCellSize = 40
+
+	// imageCitySize is the size in pixels of a city box
+	imageCitySize = 26
+
+	// imageRoadWidth is the width in pixels of a road
+	imageRoadWidth = 2
+
+	// imageAlienRadius is the radius in pixels of an alien dot
+	imageAlienRadius = 3
+
+	// gifFrameDelay is the delay between two frames of an animated GIF, in 100ths of a second
+	gifFrameDelay = 20
+
+	// gifLastFrameDelay is the delay of the last frame of an animated GIF, in 100ths of a second
+	gifLastFrameDelay = 200
+)
+
+// Indexes of the colors of the image palette
+const (
+	backgroundColor uint8 = iota
+	roadColor
+	cityColor
+	cityBorderColor
+	destroyedCityColor
+	destroyedCityBorderColor
+	alienColors
+)
+
+// imagePalette is the palette of the rendered images, the aliens colors being picked in turn given their ids
+var imagePalette = color.Palette{
+	color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	color.RGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff},
+	color.RGBA{R: 0xcc, G: 0xe0, B: 0xf5, A: 0xff},
+	color.RGBA{R: 0x1f, G: 0x4e, B: 0x79, A: 0xff},
+	color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff},
+	color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff},
+	color.RGBA{R: 0xe4, G: 0x1a, B: 0x1c, A: 0xff},
+	color.RGBA{R: 0x4d, G: 0xaf, B: 0x4a, A: 0xff},
+	color.RGBA{R: 0x98, G: 0x4e, B: 0xa3, A: 0xff},
+	color.RGBA{R: 0xff, G: 0x7f, B: 0x00, A: 0xff},
+	color.RGBA{R: 0xa6, G: 0x56, B: 0x28, A: 0xff},
+	color.RGBA{R: 0xf7, G: 0x81, B: 0xbf, A: 0xff},
+	color.RGBA{R: 0x37, G: 0x7e, B: 0xb8, A: 0xff},
+	color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+}
+
+// ImageRenderer is a renderer of a world on its inferred grid layout as an image
+// The cities are drawn as boxes, the roads as lines and the untrapped aliens as coloured dots, the destroyed cities being greyed out and crossed
+type ImageRenderer struct {
+	// Layout of the cities
+	layout *Layout
+
+	// Destroyed city names
+	destroyedCities map[string]bool
+}
+
+// NewImageRenderer is an image renderer constructor
+func NewImageRenderer(layout *Layout) *ImageRenderer {
+	return &ImageRenderer{
+		layout:          layout,
+		destroyedCities: make(map[string]bool),
+	}
+}
+
+// AddDestroyedCity records a destroyed city so that it is still drawn
+func (r *ImageRenderer) AddDestroyedCity(cityName string) {
+	r.destroyedCities[cityName] = true
+}
+
+// Render renders the current state of a world as a paletted image
+func (r *ImageRenderer) Render(ctx context.Context, world WorldStorer) (*image.Paletted, error) {
+	width, height := r.layout.Size()
+	img := image.NewPaletted(image.Rect(0, 0, maxInt(width, 1)*imageCellSize, maxInt(height, 1)*imageCellSize), imagePalette)
+	fillRect(img, img.Bounds(), backgroundColor)
+
+	// Roads first, so that the cities are drawn over them
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			position := Position{X: x, Y: y}
+			city, err := r.aliveCity(ctx, world, position)
+			if err != nil {
+				return nil, err
+			}
+			if city == nil {
+				continue
+			}
+			for _, direction := range []entity.Direction{entity.East, entity.South} {
+				neighbour, err := r.aliveCity(ctx, world, position.move(direction))
+				if err != nil {
+					return nil, err
+				}
+				road, err := roadBetween(city, neighbour, direction)
+				if err != nil {
+					return nil, err
+				}
+				if road == noRoad {
+					continue
+				}
+				fromX, fromY := cellCenter(position)
+				toX, toY := cellCenter(position.move(direction))
+				fillRect(img, image.Rect(fromX-imageRoadWidth/2, fromY-imageRoadWidth/2, toX+imageRoadWidth/2, toY+imageRoadWidth/2), roadColor)
+			}
+		}
+	}
+
+	// Cities and aliens
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			position := Position{X: x, Y: y}
+			cityName := r.layout.CityAt(position)
+			if cityName == "" {
+				continue
+			}
+			centerX, centerY := cellCenter(position)
+			box := image.Rect(centerX-imageCitySize/2, centerY-imageCitySize/2, centerX+imageCitySize/2, centerY+imageCitySize/2)
+			city, err := world.GetCity(ctx, cityName)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case city != nil:
+				fillRect(img, box, cityBorderColor)
+				fillRect(img, box.Inset(1), cityColor)
+				aliens, err := world.GetAliensAtCity(ctx, city)
+				if err != nil {
+					return nil, err
+				}
+				drawAliens(img, box, aliens)
+			case r.destroyedCities[cityName]:
+				fillRect(img, box, destroyedCityBorderColor)
+				fillRect(img, box.Inset(1), destroyedCityColor)
+				for i := 0; i < imageCitySize; i++ {
+					img.SetColorIndex(box.Min.X+i, box.Min.Y+i, destroyedCityBorderColor)
+					img.SetColorIndex(box.Max.X-1-i, box.Min.Y+i, destroyedCityBorderColor)
+				}
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// aliveCity retrieves the alive city at a position of the layout (nil if none)
+func (r *ImageRenderer) aliveCity(ctx context.Context, world WorldStorer, position Position) (*entity.City, error) {
+	cityName := r.layout.CityAt(position)
+	if cityName == "" {
+		return nil, nil
+	}
+	return world.GetCity(ctx, cityName)
+}
+
+// cellCenter computes the center of a grid position in pixels
+func cellCenter(position Position) (int, int) {
+	return position.X*imageCellSize + imageCellSize/2, position.Y*imageCellSize + imageCellSize/2
+}
+
+// fillRect fills a rectangle with a color of the palette
+func fillRect(img *image.Paletted, rect image.Rectangle, colorIndex uint8) {
+	draw.Draw(img, rect, &image.Uniform{C: imagePalette[colorIndex]}, image.Point{}, draw.Src)
+}
+
+// drawAliens draws the aliens of a city as dots on a 3 x 3 grid inside its box, in their arrival order
+func drawAliens(img *image.Paletted, box image.Rectangle, aliens []*entity.Alien) {
+	spacing := box.Dx() / 4
+	for i, alien := range aliens {
+		centerX := box.Min.X + spacing*(1+i%3)
+		centerY := box.Min.Y + spacing*(1+(i/3)%3)
+		colorIndex := alienColors + uint8((alien.AlienID-1)%(len(imagePalette)-int(alienColors)))
+		for dy := -imageAlienRadius; dy <= imageAlienRadius; dy++ {
+			for dx := -imageAlienRadius; dx <= imageAlienRadius; dx++ {
+				if dx*dx+dy*dy <= imageAlienRadius*imageAlienRadius {
+					img.SetColorIndex(centerX+dx, centerY+dy, colorIndex)
+				}
+			}
+		}
+	}
+}
+
+// ImageObserver is an observer that renders the world as a PNG image when the simulation is finished,
+// or as an animated GIF image with a frame every given number of steps and a frame of the final state
+// The layout is inferred when the first event is notified, before any city is destroyed
+type ImageObserver struct {
+	// World of the simulation
+	world WorldStorer
+
+	// Is the image animated
+	animated bool
+
+	// Number of steps between two frames of the animation
+	frameInterval uint
+
+	// Image renderer (nil until the layout is inferred)
+	renderer *ImageRenderer
+
+	// Frames of the animation
+	frames []*image.Paletted
+
+	// Does the last frame show the current state of the world
+	lastFrameCurrent bool
+
+	// Output writer
+	out io.Writer
+}
+
+var _ Observer = (*ImageObserver)(nil)
+
+// NewPNGObserver is an image observer constructor for a PNG image of the final state of the world
+func NewPNGObserver(world WorldStorer, out io.Writer) *ImageObserver {
+	return &ImageObserver{
+		world: world,
+		out:   out,
+	}
+}
+
+// NewGIFObserver is an image observer constructor for an animated GIF image of the world across the steps
+func NewGIFObserver(world WorldStorer, out io.Writer) *ImageObserver {
+	return &ImageObserver{
+		world:         world,
+		animated:      true,
+		frameInterval: 1,
+		frames:        make([]*image.Paletted, 0),
+		out:           out,
+	}
+}
+
+// SetFrameInterval sets the number of steps between two frames of the animation, to limit its size for long simulations
+func (o *ImageObserver) SetFrameInterval(frameInterval uint) {
+	o.frameInterval = frameInterval
+}
+
+// Notify notifies the observer of a simulation event
+func (o *ImageObserver) Notify(ctx context.Context, event *Event) error {
+	if o.renderer == nil {
+		layout, err := NewLayout(ctx, o.world)
+		if err != nil {
+			return err
+		}
+		o.renderer = NewImageRenderer(layout)
+	}
+	switch event.Type {
+	case CityDestroyed:
+		o.renderer.AddDestroyedCity(event.City.Name)
+	case StepStarted:
+		// The first frame shows the spawned aliens
+		if o.animated && len(o.frames) == 0 {
+			return o.addFrame(ctx)
+		}
+	case StepEnded:
+		if o.animated && o.frameInterval > 0 && event.Step%o.frameInterval == 0 {
+			return o.addFrame(ctx)
+		}
+		o.lastFrameCurrent = false
+	case SimulationFinished:
+		if !o.animated {
+			img, err := o.renderer.Render(ctx, o.world)
+			if err != nil {
+				return err
+			}
+			return png.Encode(o.out, img)
+		}
+		if !o.lastFrameCurrent {
+			err := o.addFrame(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return o.writeGIF()
+	}
+	return nil
+}
+
+// addFrame adds a frame of the current state of the world to the animation
+func (o *ImageObserver) addFrame(ctx context.Context) error {
+	img, err := o.renderer.Render(ctx, o.world)
+	if err != nil {
+		return err
+	}
+	o.frames = append(o.frames, img)
+	o.lastFrameCurrent = true
+	return nil
+}
+
+// writeGIF writes the animation, the last frame lasting longer
+func (o *ImageObserver) writeGIF() error {
+	animation := &gif.GIF{
+		Image: o.frames,
+		Delay: make([]int, len(o.frames)),
+	}
+	for i := range animation.Delay {
+		animation.Delay[i] = gifFrameDelay
+	}
+	animation.Delay[len(animation.Delay)-1] = gifLastFrameDelay
+	return gif.EncodeAll(o.out, animation)
+}
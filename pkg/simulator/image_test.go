@@ -0,0 +1,133 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_ImageRenderer_Render(t *testing.T) {
+	ctx := context.Background()
+
+	world := loadTestWorld(t, `
+City1 east=City2 south=City3
+City2 west=City1
+City3 north=City1
+`)
+	layout, err := NewLayout(ctx, world)
+	require.NoError(t, err)
+	renderer := NewImageRenderer(layout)
+	city1, err := world.GetCity(ctx, "City1")
+	require.NoError(t, err)
+	city3, err := world.GetCity(ctx, "City3")
+	require.NoError(t, err)
+	alien, err := world.AddAlien(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, world.MoveAlien(ctx, alien, city1))
+	require.NoError(t, world.DestroyCity(ctx, city3))
+	renderer.AddDestroyedCity(city3.Name)
+
+	img, err := renderer.Render(ctx, world)
+	require.NoError(t, err)
+	require.Equal(t, 2*imageCellSize, img.Bounds().Dx())
+	require.Equal(t, 2*imageCellSize, img.Bounds().Dy())
+
+	// City1 at (0, 0) with an alien, linked to City2 at (1, 0)
+	centerX, centerY := cellCenter(Position{X: 0, Y: 0})
+	box := imageCitySize / 2
+	require.Equal(t, cityBorderColor, img.ColorIndexAt(centerX-box, centerY-box))
+	require.Equal(t, cityColor, img.ColorIndexAt(centerX, centerY+box-2))
+	require.Equal(t, alienColors, img.ColorIndexAt(centerX-box+imageCitySize/4, centerY-box+imageCitySize/4))
+	require.Equal(t, roadColor, img.ColorIndexAt(imageCellSize, centerY))
+	require.Equal(t, backgroundColor, img.ColorIndexAt(imageCellSize, centerY+imageRoadWidth))
+
+	// City3 at (0, 1) is destroyed, and its road is gone
+	centerX, centerY = cellCenter(Position{X: 0, Y: 1})
+	require.Equal(t, destroyedCityBorderColor, img.ColorIndexAt(centerX, centerY))
+	require.Equal(t, destroyedCityColor, img.ColorIndexAt(centerX, centerY+box-2))
+	require.Equal(t, backgroundColor, img.ColorIndexAt(centerX, imageCellSize))
+
+	// Nothing at (1, 1)
+	centerX, centerY = cellCenter(Position{X: 1, Y: 1})
+	require.Equal(t, backgroundColor, img.ColorIndexAt(centerX, centerY))
+}
+
+func Test_ImageObserver(t *testing.T) {
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name              string
+		giveAnimated      bool
+		giveFrameInterval uint
+		wantFrames        int
+	}{
+		{"PNG", false, 0, 1},
+		{"GIF", true, 1, 4},
+		{"GIF every 2 steps", true, 2, 3},
+		{"GIF without intermediate frames", true, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			out := &bytes.Buffer{}
+			observer := NewPNGObserver(world, out)
+			if tt.giveAnimated {
+				observer = NewGIFObserver(world, out)
+				observer.SetFrameInterval(tt.giveFrameInterval)
+			}
+			s := NewSimulationEngine(1, 3, world, NewRandomSeeded(42), strings.NewReader(input), nil)
+			s.AddObserver(observer)
+			require.NoError(t, s.Run(context.Background()))
+
+			if !tt.giveAnimated {
+				img, err := png.Decode(out)
+				require.NoError(t, err)
+				require.Equal(t, 2*imageCellSize, img.Bounds().Dx())
+				return
+			}
+			animation, err := gif.DecodeAll(out)
+			require.NoError(t, err)
+			require.Len(t, animation.Image, tt.wantFrames)
+			require.Equal(t, gifLastFrameDelay, animation.Delay[len(animation.Delay)-1])
+		})
+	}
+}
+
+func Test_drawAliens_Colors(t *testing.T) {
+	alienColorsCount := len(imagePalette) - int(alienColors)
+	world := loadTestWorld(t, "City1")
+	ctx := context.Background()
+	city, err := world.GetCity(ctx, "City1")
+	require.NoError(t, err)
+	aliens := make([]*entity.Alien, 0)
+	for _, alienID := range []int{1, 1 + alienColorsCount} {
+		alien, err := world.AddAlien(ctx, alienID)
+		require.NoError(t, err)
+		require.NoError(t, world.MoveAlien(ctx, alien, city))
+		aliens = append(aliens, alien)
+	}
+	layout, err := NewLayout(ctx, world)
+	require.NoError(t, err)
+	img, err := NewImageRenderer(layout).Render(ctx, world)
+	require.NoError(t, err)
+
+	// The aliens colors are picked in turn
+	centerX, centerY := cellCenter(Position{})
+	spacing := imageCitySize / 4
+	left, top := centerX-imageCitySize/2, centerY-imageCitySize/2
+	require.Len(t, aliens, 2)
+	require.Equal(t, alienColors, img.ColorIndexAt(left+spacing, top+spacing))
+	require.Equal(t, alienColors, img.ColorIndexAt(left+2*spacing, top+spacing))
+}
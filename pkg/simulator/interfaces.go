@@ -2,6 +2,7 @@ package simulator
 
 import (
 	"context"
+	"io"
 
 	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
 )
@@ -28,8 +29,8 @@ type WorldStorer interface {
 	IsTrappedAlien(ctx context.Context, alien *entity.Alien) (bool, error)
 	// TrapAlien traps an alien
 	TrapAlien(ctx context.Context, alien *entity.Alien) error
-	// GetAlienAtCity retrieves the alien at a given city
-	GetAlienAtCity(ctx context.Context, city *entity.City) (*entity.Alien, error)
+	// GetAliensAtCity retrieves the untrapped aliens at a given city
+	GetAliensAtCity(ctx context.Context, city *entity.City) ([]*entity.Alien, error)
 	// GetUntrappedAliens retrieves the list of untrapped aliens
 	GetUntrappedAliens(ctx context.Context) ([]*entity.Alien, error)
 }
@@ -46,6 +47,38 @@ type Simulator interface {
 	Run(ctx context.Context) error
 	// Finalize finalizes the simulation
 	Finalize(ctx context.Context) error
+	// Outcome retrieves the outcome of the simulation
+	Outcome(ctx context.Context) (*Outcome, error)
+	// Checkpoint takes a snapshot of the complete state of the simulation
+	Checkpoint(ctx context.Context) (*Checkpoint, error)
+}
+
+// Observer is a simulation events observer
+type Observer interface {
+	// Notify notifies the observer of a simulation event
+	Notify(ctx context.Context, event *Event) error
+}
+
+// MovementStrategy is an alien movement strategy
+type MovementStrategy interface {
+	// NextCity chooses the next city of an alien (nil if the alien stays in its city)
+	NextCity(ctx context.Context, alien *entity.Alien, world WorldStorer, random Randomer) (*entity.City, error)
+	// String retrieves the name of the movement strategy
+	String() string
+}
+
+// MapReader is a world map reader
+type MapReader interface {
+	// Read reads a world map definition, it returns a *ParseError describing the problems found, if any
+	Read(in io.Reader) (*MapDefinition, error)
+	// SetCollectAll sets whether all the problems are collected instead of stopping at the first one
+	SetCollectAll(collectAll bool)
+}
+
+// MapWriter is a world map writer
+type MapWriter interface {
+	// Write writes the alive cities of a world and their links, so that the output can be read back by a map reader
+	Write(ctx context.Context, world WorldStorer, out io.Writer) error
 }
 
 // Randomer is a random generator
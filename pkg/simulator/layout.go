@@ -0,0 +1,216 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// Position is a position on a grid, the columns growing eastwards and the rows growing southwards
+type Position struct {
+	// Column
+	X int
+
+	// Row
+	Y int
+}
+
+// String implements Stringer interface for a position
+func (p Position) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+// move returns the neighbour position in a direction
+func (p Position) move(direction entity.Direction) Position {
+	switch direction {
+	case entity.North:
+		return Position{X: p.X, Y: p.Y - 1}
+	case entity.East:
+		return Position{X: p.X + 1, Y: p.Y}
+	case entity.South:
+		return Position{X: p.X, Y: p.Y + 1}
+	case entity.West:
+		return Position{X: p.X - 1, Y: p.Y}
+	default:
+		return p
+	}
+}
+
+// LayoutConflict is a link that doesn't match the grid positions of its cities
+type LayoutConflict struct {
+	// Origin city name
+	CityFrom string
+
+	// Direction of the link
+	Direction entity.Direction
+
+	// Destination city name
+	CityTo string
+
+	// Position where the destination city was expected
+	Expected Position
+
+	// Position where the destination city is placed
+	Placed Position
+
+	// City already placed at the expected position (empty if none)
+	CityAt string
+}
+
+// String implements Stringer interface for a layout conflict
+func (lc *LayoutConflict) String() string {
+	from, to := entity.QuoteCityName(lc.CityFrom), entity.QuoteCityName(lc.CityTo)
+	if lc.CityAt != "" {
+		return fmt.Sprintf("%s %s=%s but %s is already placed at %s", from, lc.Direction, to, entity.QuoteCityName(lc.CityAt), lc.Expected)
+	}
+	return fmt.Sprintf("%s %s=%s but %s is placed at %s instead of %s", from, lc.Direction, to, to, lc.Placed, lc.Expected)
+}
+
+// Layout is an assignment of grid positions to the cities of a world, inferred from the directions of their links
+type Layout struct {
+	// Positions of the cities by city name
+	positions map[string]Position
+
+	// Cities by position
+	cities map[Position]string
+
+	// Size of the grid
+	width, height int
+
+	// Links that don't match the positions of their cities
+	conflicts []*LayoutConflict
+}
+
+// NewLayout infers the layout of the alive cities of a world by walking their links
+// The connected cities are placed one step apart in the direction of their links, the first city placed winning a position
+// The groups of connected cities are placed side by side from west to east, in the input order of their first city
+func NewLayout(ctx context.Context, world WorldStorer) (*Layout, error) {
+	log.Debug("NewLayout")
+
+	cities, links, err := aliveCityLinks(ctx, world)
+	if err != nil {
+		return nil, err
+	}
+
+	// The links are walked both ways so that one-way links connect their cities too
+	neighbours := make(map[*entity.City][]*cityLink, len(cities))
+	for _, city := range cities {
+		for _, link := range links[city] {
+			neighbours[city] = append(neighbours[city], link)
+			neighbours[link.cityTo] = append(neighbours[link.cityTo], &cityLink{direction: link.direction.Opposite(), cityTo: city})
+		}
+	}
+
+	l := &Layout{
+		positions: make(map[string]Position, len(cities)),
+		cities:    make(map[Position]string, len(cities)),
+		conflicts: make([]*LayoutConflict, 0),
+	}
+	placed := make(map[*entity.City]bool, len(cities))
+	for _, city := range cities {
+		if placed[city] {
+			continue
+		}
+
+		// Place the connected cities with a breadth first walk
+		group := map[*entity.City]Position{city: {}}
+		groupCities := map[Position]*entity.City{{}: city}
+		queue := []*entity.City{city}
+		placed[city] = true
+		for len(queue) > 0 {
+			cityFrom := queue[0]
+			queue = queue[1:]
+			for _, link := range neighbours[cityFrom] {
+				if placed[link.cityTo] {
+					continue
+				}
+				position := group[cityFrom].move(link.direction)
+				if _, taken := groupCities[position]; taken {
+					continue
+				}
+				group[link.cityTo] = position
+				groupCities[position] = link.cityTo
+				placed[link.cityTo] = true
+				queue = append(queue, link.cityTo)
+			}
+		}
+
+		// Shift the group to the east of the previous groups
+		minX, minY, maxX, maxY := 0, 0, 0, 0
+		for _, position := range group {
+			minX, minY = minInt(minX, position.X), minInt(minY, position.Y)
+			maxX, maxY = maxInt(maxX, position.X), maxInt(maxY, position.Y)
+		}
+		offsetX := l.width
+		if offsetX > 0 {
+			offsetX++
+		}
+		for groupCity, position := range group {
+			position = Position{X: position.X - minX + offsetX, Y: position.Y - minY}
+			l.positions[groupCity.Name] = position
+			l.cities[position] = groupCity.Name
+		}
+		l.width = offsetX + maxX - minX + 1
+		l.height = maxInt(l.height, maxY-minY+1)
+	}
+
+	// Report the links that don't match the positions
+	for _, city := range cities {
+		for _, link := range links[city] {
+			expected := l.positions[city.Name].move(link.direction)
+			if l.positions[link.cityTo.Name] == expected {
+				continue
+			}
+			l.conflicts = append(l.conflicts, &LayoutConflict{
+				CityFrom:  city.Name,
+				Direction: link.direction,
+				CityTo:    link.cityTo.Name,
+				Expected:  expected,
+				Placed:    l.positions[link.cityTo.Name],
+				CityAt:    l.cities[expected],
+			})
+		}
+	}
+
+	return l, nil
+}
+
+// Position retrieves the position of a city given its name
+func (l *Layout) Position(cityName string) (Position, bool) {
+	position, found := l.positions[cityName]
+	return position, found
+}
+
+// CityAt retrieves the name of the city placed at a position (empty if none)
+func (l *Layout) CityAt(position Position) string {
+	return l.cities[position]
+}
+
+// Size retrieves the number of columns and rows of the grid
+func (l *Layout) Size() (width, height int) {
+	return l.width, l.height
+}
+
+// Conflicts retrieves the links that don't match the positions of their cities, in the input order of their origin city
+func (l *Layout) Conflicts() []*LayoutConflict {
+	return l.conflicts
+}
+
+// minInt returns the minimum of two integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the maximum of two integers
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
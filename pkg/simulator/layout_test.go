@@ -0,0 +1,121 @@
+package simulator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_NewLayout(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantPositions map[string]Position
+		wantSize      Position
+		wantConflicts []string
+	}{
+		{
+			name: "Consistent map",
+			input: `
+Paris north=Brussels west=London
+Brussels south=Paris
+London east=Paris
+`,
+			wantPositions: map[string]Position{
+				"Paris":    {X: 1, Y: 1},
+				"Brussels": {X: 1, Y: 0},
+				"London":   {X: 0, Y: 1},
+			},
+			wantSize:      Position{X: 2, Y: 2},
+			wantConflicts: []string{},
+		},
+		{
+			name: "One-way links and disconnected cities",
+			input: `
+City1 east=City2
+City3 south=City2
+City4
+`,
+			wantPositions: map[string]Position{
+				"City1": {X: 0, Y: 1},
+				"City2": {X: 1, Y: 1},
+				"City3": {X: 1, Y: 0},
+				"City4": {X: 3, Y: 0},
+			},
+			wantSize:      Position{X: 4, Y: 2},
+			wantConflicts: []string{},
+		},
+		{
+			name: "Conflicting links",
+			input: `
+City1 north=City2 east=City3
+City3 north=City4
+City4 west=City2 east=City1
+`,
+			wantPositions: map[string]Position{
+				"City1": {X: 1, Y: 1},
+				"City2": {X: 1, Y: 0},
+				"City3": {X: 2, Y: 1},
+				"City4": {X: 0, Y: 1},
+			},
+			wantSize: Position{X: 3, Y: 2},
+			wantConflicts: []string{
+				"City3 north=City4 but City4 is placed at (0, 1) instead of (2, 0)",
+				"City4 west=City2 but City2 is placed at (1, 0) instead of (-1, 1)",
+			},
+		},
+		{
+			name: "Position already taken",
+			input: `
+City1 north=City2 east=City3
+City3 north=City4
+City2 east=City5
+City4 west=City5
+`,
+			wantPositions: map[string]Position{
+				"City1": {X: 0, Y: 1},
+				"City2": {X: 0, Y: 0},
+				"City3": {X: 1, Y: 1},
+				"City4": {X: 2, Y: 0},
+				"City5": {X: 1, Y: 0},
+			},
+			wantSize: Position{X: 3, Y: 2},
+			wantConflicts: []string{
+				"City3 north=City4 but City5 is already placed at (1, 0)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := loadTestWorld(t, tt.input)
+			layout, err := NewLayout(context.Background(), world)
+			require.NoError(t, err)
+			for cityName, wantPosition := range tt.wantPositions {
+				position, found := layout.Position(cityName)
+				require.True(t, found, cityName)
+				require.Equal(t, wantPosition, position, cityName)
+				require.Equal(t, cityName, layout.CityAt(position))
+			}
+			width, height := layout.Size()
+			require.Equal(t, tt.wantSize, Position{X: width, Y: height})
+			conflicts := make([]string, 0)
+			for _, conflict := range layout.Conflicts() {
+				conflicts = append(conflicts, conflict.String())
+			}
+			require.Equal(t, tt.wantConflicts, conflicts)
+		})
+	}
+}
+
+func Test_Position_move(t *testing.T) {
+	position := Position{X: 3, Y: 5}
+	require.Equal(t, Position{X: 3, Y: 4}, position.move(entity.North))
+	require.Equal(t, Position{X: 4, Y: 5}, position.move(entity.East))
+	require.Equal(t, Position{X: 3, Y: 6}, position.move(entity.South))
+	require.Equal(t, Position{X: 2, Y: 5}, position.move(entity.West))
+	require.Equal(t, "(3, 5)", position.String())
+}
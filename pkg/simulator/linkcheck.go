@@ -0,0 +1,233 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// LinkCheckMode represents what is done when the links of the world are geometrically inconsistent
+type LinkCheckMode int
+
+const (
+	// LinkCheckOff link check mode does not check the links
+	LinkCheckOff LinkCheckMode = iota
+
+	// LinkCheckWarn link check mode logs a warning for every inconsistent link
+	LinkCheckWarn
+
+	// LinkCheckReject link check mode fails when a link is inconsistent
+	LinkCheckReject
+
+	// LinkCheckRepair link check mode inserts the missing reverse links, and logs a warning for the links that can't be repaired
+	LinkCheckRepair
+)
+
+// String implements Stringer interface for a link check mode
+func (m LinkCheckMode) String() string {
+	switch m {
+	case LinkCheckOff:
+		return "off"
+	case LinkCheckWarn:
+		return "warn"
+	case LinkCheckReject:
+		return "reject"
+	case LinkCheckRepair:
+		return "repair"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLinkCheckMode parses a link check mode from its name
+func ParseLinkCheckMode(name string) (LinkCheckMode, error) {
+	for _, mode := range []LinkCheckMode{LinkCheckOff, LinkCheckWarn, LinkCheckReject, LinkCheckRepair} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return LinkCheckOff, entity.ErrUnknownLinkCheckMode
+}
+
+// LinkIssueKind represents the kind of a geometric inconsistency of a link
+type LinkIssueKind int
+
+const (
+	// MissingReverseLink is a link whose destination city has no link back in the opposite direction
+	MissingReverseLink LinkIssueKind = iota + 1
+
+	// ConflictingReverseLink is a link whose destination city links to another city in the opposite direction
+	ConflictingReverseLink
+
+	// ContradictoryLink is a link whose destination city links back through another direction than the opposite one
+	ContradictoryLink
+)
+
+// String implements Stringer interface for a link issue kind
+func (k LinkIssueKind) String() string {
+	switch k {
+	case MissingReverseLink:
+		return "missing_reverse_link"
+	case ConflictingReverseLink:
+		return "conflicting_reverse_link"
+	case ContradictoryLink:
+		return "contradictory_link"
+	default:
+		return "unknown"
+	}
+}
+
+// LinkIssue is a geometric inconsistency of a link
+type LinkIssue struct {
+	// Kind of inconsistency
+	Kind LinkIssueKind
+
+	// Inconsistent link
+	CityFrom  string
+	Direction entity.Direction
+	CityTo    string
+
+	// Link of the destination city that makes the link inconsistent (missing for a missing reverse link)
+	ReverseDirection entity.Direction
+	ReverseCityTo    string
+}
+
+// String implements Stringer interface for a link issue
+func (li *LinkIssue) String() string {
+	cityFrom, cityTo := entity.QuoteCityName(li.CityFrom), entity.QuoteCityName(li.CityTo)
+	switch li.Kind {
+	case MissingReverseLink:
+		return fmt.Sprintf("%s %s=%s has no reverse link %s %s=%s", cityFrom, li.Direction, cityTo, cityTo, li.ReverseDirection, cityFrom)
+	default:
+		return fmt.Sprintf("%s %s=%s but %s %s=%s", cityFrom, li.Direction, cityTo, cityTo, li.ReverseDirection, entity.QuoteCityName(li.ReverseCityTo))
+	}
+}
+
+// LinkCheckError is the error triggered when the links of the world are rejected
+// It matches ErrInconsistentLinks with errors.Is
+type LinkCheckError struct {
+	// Inconsistent links
+	Issues []*LinkIssue
+}
+
+// Error implements error interface for a link check error
+func (le *LinkCheckError) Error() string {
+	issues := make([]string, 0, len(le.Issues))
+	for _, issue := range le.Issues {
+		issues = append(issues, issue.String())
+	}
+	return fmt.Sprintf("%s: %s", entity.ErrInconsistentLinks, strings.Join(issues, "; "))
+}
+
+// Is checks if a link check error matches a target error
+func (le *LinkCheckError) Is(target error) bool {
+	return target == entity.ErrInconsistentLinks
+}
+
+// CheckLinks analyzes the links between the alive cities of the world and reports the geometrically inconsistent ones
+// Cities are checked in their input order and links in the directions order
+// A contradictory couple of links is reported once
+func CheckLinks(ctx context.Context, world WorldStorer) ([]*LinkIssue, error) {
+	log.Debug("CheckLinks")
+
+	aliveCities, err := world.GetAliveCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]*LinkIssue, 0)
+	reportedContradictions := make(map[*entity.City]map[entity.Direction]bool)
+	for _, cityFrom := range aliveCities {
+		for _, direction := range entity.Directions {
+			cityTo, err := cityFrom.GetCityTo(direction)
+			if err != nil {
+				return nil, err
+			}
+			if cityTo == nil || reportedContradictions[cityFrom][direction] {
+				continue
+			}
+			reverseDirection := direction.Opposite()
+			reverseCityTo, err := cityTo.GetCityTo(reverseDirection)
+			if err != nil {
+				return nil, err
+			}
+			if reverseCityTo == cityFrom {
+				continue
+			}
+			issue := &LinkIssue{
+				Kind:             MissingReverseLink,
+				CityFrom:         cityFrom.Name,
+				Direction:        direction,
+				CityTo:           cityTo.Name,
+				ReverseDirection: reverseDirection,
+			}
+			// The destination city links back through another direction
+			for _, backDirection := range entity.Directions {
+				backCityTo, err := cityTo.GetCityTo(backDirection)
+				if err != nil {
+					return nil, err
+				}
+				if backCityTo == cityFrom {
+					issue.Kind = ContradictoryLink
+					issue.ReverseDirection = backDirection
+					issue.ReverseCityTo = cityFrom.Name
+					if _, found := reportedContradictions[cityTo]; !found {
+						reportedContradictions[cityTo] = make(map[entity.Direction]bool)
+					}
+					reportedContradictions[cityTo][backDirection] = true
+					break
+				}
+			}
+			if issue.Kind == MissingReverseLink && reverseCityTo != nil {
+				issue.Kind = ConflictingReverseLink
+				issue.ReverseCityTo = reverseCityTo.Name
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// RepairLinks inserts the missing reverse links between the alive cities of the world
+// It returns the inserted links, conflicting and contradictory links are left untouched
+func RepairLinks(ctx context.Context, world WorldStorer) ([]*LinkIssue, error) {
+	log.Debug("RepairLinks")
+
+	issues, err := CheckLinks(ctx, world)
+	if err != nil {
+		return nil, err
+	}
+	repaired := make([]*LinkIssue, 0)
+	for _, issue := range issues {
+		if issue.Kind != MissingReverseLink {
+			continue
+		}
+		cityFrom, err := world.GetCity(ctx, issue.CityFrom)
+		if err != nil {
+			return nil, err
+		}
+		cityTo, err := world.GetCity(ctx, issue.CityTo)
+		if err != nil {
+			return nil, err
+		}
+		// A previous repair may have used the same direction of the destination city
+		reverseCityTo, err := cityTo.GetCityTo(issue.ReverseDirection)
+		if err != nil {
+			return nil, err
+		}
+		if reverseCityTo != nil {
+			continue
+		}
+		err = world.AddLink(ctx, cityTo, cityFrom, issue.ReverseDirection)
+		if err != nil {
+			return nil, err
+		}
+		repaired = append(repaired, issue)
+	}
+
+	return repaired, nil
+}
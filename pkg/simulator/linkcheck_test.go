@@ -0,0 +1,150 @@
+package simulator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_ParseLinkCheckMode(t *testing.T) {
+	tests := []struct {
+		giveName  string
+		wantMode  LinkCheckMode
+		wantError error
+	}{
+		{"off", LinkCheckOff, nil},
+		{"warn", LinkCheckWarn, nil},
+		{"reject", LinkCheckReject, nil},
+		{"repair", LinkCheckRepair, nil},
+		{"fix", LinkCheckOff, entity.ErrUnknownLinkCheckMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.giveName, func(t *testing.T) {
+			mode, err := ParseLinkCheckMode(tt.giveName)
+			require.Equal(t, tt.wantError, err)
+			require.Equal(t, tt.wantMode, mode)
+		})
+	}
+}
+
+// loadTestWorld loads a world map in a new world
+func loadTestWorld(t *testing.T, input string) *World {
+	ctx := context.Background()
+	definition, err := NewTextMapReader("").Read(strings.NewReader(input))
+	require.NoError(t, err)
+	world := NewWorld()
+	require.NoError(t, definition.LoadToWorld(ctx, world))
+	return world
+}
+
+func Test_CheckLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantIssues []string
+	}{
+		{
+			name:       "Case 1: consistent links",
+			input:      "Paris north=Brussels\nBrussels south=Paris\n",
+			wantIssues: []string{},
+		},
+		{
+			name:       "Case 2: missing reverse link",
+			input:      "Paris north=Brussels\n",
+			wantIssues: []string{"Paris north=Brussels has no reverse link Brussels south=Paris"},
+		},
+		{
+			name:       "Case 3: conflicting reverse link",
+			input:      "Paris north=Brussels\nBrussels south=Amsterdam\nAmsterdam north=Brussels\n",
+			wantIssues: []string{"Paris north=Brussels but Brussels south=Amsterdam"},
+		},
+		{
+			name:       "Case 4: contradictory links are reported once",
+			input:      "London west=Paris\nParis west=London\n",
+			wantIssues: []string{"London west=Paris but Paris west=London"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := loadTestWorld(t, tt.input)
+			issues, err := CheckLinks(context.Background(), world)
+			require.NoError(t, err)
+			gotIssues := make([]string, 0)
+			for _, issue := range issues {
+				gotIssues = append(gotIssues, issue.String())
+			}
+			require.Equal(t, tt.wantIssues, gotIssues)
+		})
+	}
+}
+
+func Test_RepairLinks(t *testing.T) {
+	ctx := context.Background()
+
+	// Paris and Lyon both expect to be south of Brussels, only the first one is repaired
+	world := loadTestWorld(t, `
+Paris north=Brussels west=London
+Lyon north=Brussels
+London west=Paris
+`)
+	repaired, err := RepairLinks(ctx, world)
+	require.NoError(t, err)
+	require.Len(t, repaired, 1)
+	require.Equal(t, "Paris north=Brussels has no reverse link Brussels south=Paris", repaired[0].String())
+
+	brussels, err := world.GetCity(ctx, "Brussels")
+	require.NoError(t, err)
+	require.Equal(t, "Paris", brussels.South.Name)
+
+	issues, err := CheckLinks(ctx, world)
+	require.NoError(t, err)
+	gotIssues := make([]string, 0)
+	for _, issue := range issues {
+		gotIssues = append(gotIssues, issue.String())
+	}
+	require.Equal(t, []string{
+		"Paris west=London but London west=Paris",
+		"Lyon north=Brussels but Brussels south=Paris",
+	}, gotIssues)
+}
+
+func Test_SimulationEngine_checkLinks(t *testing.T) {
+	input := "Paris north=Brussels\nLondon west=Paris\nParis west=London\n"
+
+	tests := []struct {
+		name              string
+		giveMode          LinkCheckMode
+		wantError         error
+		wantBrusselsSouth string
+	}{
+		{"Case 1: off", LinkCheckOff, nil, ""},
+		{"Case 2: warn", LinkCheckWarn, nil, ""},
+		{"Case 3: reject", LinkCheckReject, entity.ErrInconsistentLinks, ""},
+		{"Case 4: repair", LinkCheckRepair, nil, "Paris"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			world := NewWorld()
+			s := NewSimulationEngine(0, 10, world, NewRandomSeeded(42), strings.NewReader(input), nil)
+			s.SetLinkCheckMode(tt.giveMode)
+			err := s.Prepare(ctx)
+			require.ErrorIs(t, err, tt.wantError)
+
+			brussels, err := world.GetCity(ctx, "Brussels")
+			require.NoError(t, err)
+			brusselsSouth := ""
+			if brussels.South != nil {
+				brusselsSouth = brussels.South.Name
+			}
+			require.Equal(t, tt.wantBrusselsSouth, brusselsSouth)
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+const (
+	// TextMapFormat is the whitespace delimited text format of the world maps
+	TextMapFormat = "text"
+
+	// JSONMapFormat is the JSON format of the world maps
+	JSONMapFormat = "json"
+
+	// YAMLMapFormat is the YAML format of the world maps
+	YAMLMapFormat = "yaml"
+)
+
+// NewMapReader is a map reader constructor given a map format
+// If the format is empty, it is detected from the extension of the file name (.json, .yaml or .yml), and defaults to text
+func NewMapReader(format, fileName string) (MapReader, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(fileName)) {
+		case ".json":
+			format = JSONMapFormat
+		case ".yaml", ".yml":
+			format = YAMLMapFormat
+		default:
+			format = TextMapFormat
+		}
+	}
+	switch format {
+	case TextMapFormat:
+		return NewTextMapReader(fileName), nil
+	case JSONMapFormat:
+		return NewJSONMapReader(fileName), nil
+	case YAMLMapFormat:
+		return NewYAMLMapReader(fileName), nil
+	default:
+		return nil, entity.ErrUnknownMapFormat
+	}
+}
+
+// TextMapReader is a reader of world maps in the text format
+type TextMapReader struct {
+	// Name of the map file used in the diagnostics
+	fileName string
+
+	// Collect all the problems instead of stopping at the first one
+	collectAll bool
+}
+
+var _ MapReader = (*TextMapReader)(nil)
+
+// NewTextMapReader is a text map reader constructor
+func NewTextMapReader(fileName string) *TextMapReader {
+	return &TextMapReader{
+		fileName: fileName,
+	}
+}
+
+// SetCollectAll sets whether all the problems are collected instead of stopping at the first one
+func (r *TextMapReader) SetCollectAll(collectAll bool) {
+	r.collectAll = collectAll
+}
+
+// Read reads a world map in the text format
+// It returns a *ParseError describing the problems found, if any
+func (r *TextMapReader) Read(in io.Reader) (*MapDefinition, error) {
+	log.WithFields(log.Fields{
+		"file": r.fileName,
+	}).Debug("TextMapReader Read")
+
+	builder := newMapBuilder(r.fileName)
+	scanner := bufio.NewScanner(in)
+	scanner.Split(bufio.ScanLines)
+	line := 0
+	for scanner.Scan() {
+		line++
+		tokens, quoteColumn := splitTokens(scanner.Text())
+		if quoteColumn > 0 {
+			builder.addIssue(line, quoteColumn, "", entity.ErrParseCityDefinition, "unterminated quoted name")
+			continue
+		}
+		// Skip empty lines
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens[0].separators) > 0 {
+			builder.addIssue(line, tokens[0].column, tokens[0].text, entity.ErrParseCityDefinition, "malformed city name %q, a line must start with a city name", tokens[0].text)
+		}
+		cityDefinition := builder.addCity(tokens[0].text, line, tokens[0].column)
+
+		// Parse all direction/city couples
+		for _, t := range tokens[1:] {
+			directionName, cityToName, ok := t.split()
+			if !ok || directionName == "" || cityToName == "" {
+				builder.addIssue(line, t.column, t.text, entity.ErrParseCityDefinition, "malformed link %q, expected direction=city", t.text)
+				continue
+			}
+			builder.addLink(cityDefinition, directionName, cityToName, line, t.column, t.text)
+		}
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.build(r.collectAll)
+}
+
+// token is a whitespace delimited token of a line
+type token struct {
+	// Text of the token, unquoted and unescaped
+	text string
+
+	// Column of the first character of the token (starting at 1)
+	column int
+
+	// Offsets in the text of the equal signs that are not quoted
+	separators []int
+}
+
+// split splits the token at its unquoted equal sign
+// Returns false if the token does not have exactly one unquoted equal sign
+func (t *token) split() (string, string, bool) {
+	if len(t.separators) != 1 {
+		return "", "", false
+	}
+	return t.text[:t.separators[0]], t.text[t.separators[0]+1:], true
+}
+
+// splitTokens splits a line in whitespace delimited tokens
+// A double quoted part of a token may contain whitespaces, equal signs and the \", \\, \n, \r, \t and \uXXXX escape sequences
+// Returns the column of the opening quote of an unterminated quoted part, if any
+func splitTokens(line string) ([]*token, int) {
+	tokens := make([]*token, 0)
+	var current *token
+	var text strings.Builder
+	quoteColumn := 0
+	escaped := false
+	var unicodeDigits []rune
+	column := 0
+	for _, r := range line {
+		column++
+		if unicodeDigits != nil {
+			if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				unicodeDigits = append(unicodeDigits, r)
+				if len(unicodeDigits) == 4 {
+					code, _ := strconv.ParseUint(string(unicodeDigits), 16, 32)
+					text.WriteRune(rune(code))
+					unicodeDigits = nil
+				}
+				continue
+			}
+			// Not a unicode escape sequence, kept as is
+			text.WriteString(`\u` + string(unicodeDigits))
+			unicodeDigits = nil
+		}
+		switch {
+		case quoteColumn > 0 && escaped:
+			escaped = false
+			switch r {
+			case '"', '\\':
+				text.WriteRune(r)
+			case 'n':
+				text.WriteRune('\n')
+			case 'r':
+				text.WriteRune('\r')
+			case 't':
+				text.WriteRune('\t')
+			case 'u':
+				unicodeDigits = make([]rune, 0, 4)
+			default:
+				text.WriteRune('\\')
+				text.WriteRune(r)
+			}
+			continue
+		case quoteColumn > 0 && r == '\\':
+			escaped = true
+			continue
+		case quoteColumn > 0 && r == '"':
+			quoteColumn = 0
+			continue
+		case quoteColumn > 0:
+			text.WriteRune(r)
+			continue
+		case unicode.IsSpace(r):
+			if current != nil {
+				current.text = text.String()
+				text.Reset()
+			}
+			current = nil
+			continue
+		}
+		if current == nil {
+			current = &token{column: column}
+			tokens = append(tokens, current)
+		}
+		switch r {
+		case '"':
+			quoteColumn = column
+		case '=':
+			current.separators = append(current.separators, text.Len())
+			text.WriteRune(r)
+		default:
+			text.WriteRune(r)
+		}
+	}
+	if current != nil {
+		current.text = text.String()
+	}
+	return tokens, quoteColumn
+}
+
+// DocumentMapReader is a reader of world maps in a structured document format (JSON or YAML)
+//
+// A document has an optional metadata mapping of strings, and a list of cities with their name and links:
+//
+//	metadata:
+//	  name: Europe
+//	cities:
+//	  - name: New York
+//	    links:
+//	      north: Boston
+//
+// The links of a city are added in their order of appearance
+type DocumentMapReader struct {
+	// Format of the document
+	format string
+
+	// Name of the map file used in the diagnostics
+	fileName string
+
+	// Collect all the problems instead of stopping at the first one
+	collectAll bool
+}
+
+var _ MapReader = (*DocumentMapReader)(nil)
+
+// NewJSONMapReader is a JSON map reader constructor
+func NewJSONMapReader(fileName string) *DocumentMapReader {
+	return &DocumentMapReader{
+		format:   JSONMapFormat,
+		fileName: fileName,
+	}
+}
+
+// NewYAMLMapReader is a YAML map reader constructor
+func NewYAMLMapReader(fileName string) *DocumentMapReader {
+	return &DocumentMapReader{
+		format:   YAMLMapFormat,
+		fileName: fileName,
+	}
+}
+
+// SetCollectAll sets whether all the problems are collected instead of stopping at the first one
+func (r *DocumentMapReader) SetCollectAll(collectAll bool) {
+	r.collectAll = collectAll
+}
+
+// Read reads a world map document
+// It returns a *ParseError describing the problems found, if any
+func (r *DocumentMapReader) Read(in io.Reader) (*MapDefinition, error) {
+	log.WithFields(log.Fields{
+		"file":   r.fileName,
+		"format": r.format,
+	}).Debug("DocumentMapReader Read")
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	builder := newMapBuilder(r.fileName)
+
+	// A JSON document is decoded as YAML, which keeps the positions, once it is checked to be valid JSON
+	if r.format == JSONMapFormat {
+		var document interface{}
+		err = json.Unmarshal(data, &document)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// The offset of a syntax error is the one after the invalid character
+			line, column := offsetPosition(data, syntaxErr.Offset-1)
+			builder.addIssue(line, column, "", entity.ErrParseCityDefinition, "invalid JSON: %s", syntaxErr)
+			return builder.build(r.collectAll)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	root := &yaml.Node{}
+	err = yaml.Unmarshal(data, root)
+	if err != nil {
+		builder.addIssue(0, 0, "", entity.ErrParseCityDefinition, "invalid %s: %s", strings.ToUpper(r.format), err)
+		return builder.build(r.collectAll)
+	}
+	// Empty document
+	if len(root.Content) == 0 {
+		return builder.build(r.collectAll)
+	}
+	readDocument(builder, root.Content[0])
+
+	return builder.build(r.collectAll)
+}
+
+// readDocument reads the root node of a world map document
+func readDocument(builder *mapBuilder, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		builder.addIssue(node.Line, node.Column, node.Value, entity.ErrParseCityDefinition, "expected a mapping with metadata and cities keys")
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "metadata":
+			readDocumentMetadata(builder, value)
+		case "cities":
+			if isNullNode(value) {
+				continue
+			}
+			if value.Kind != yaml.SequenceNode {
+				builder.addIssue(value.Line, value.Column, value.Value, entity.ErrParseCityDefinition, "expected a list of cities")
+				continue
+			}
+			for _, cityNode := range value.Content {
+				readDocumentCity(builder, cityNode)
+			}
+		default:
+			builder.addIssue(key.Line, key.Column, key.Value, entity.ErrParseCityDefinition, "unknown key %q, expected metadata or cities", key.Value)
+		}
+	}
+}
+
+// readDocumentMetadata reads the metadata node of a world map document
+func readDocumentMetadata(builder *mapBuilder, node *yaml.Node) {
+	if isNullNode(node) {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		builder.addIssue(node.Line, node.Column, node.Value, entity.ErrParseCityDefinition, "expected a mapping of metadata")
+		return
+	}
+	if builder.definition.Metadata == nil {
+		builder.definition.Metadata = make(map[string]string)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			builder.addIssue(value.Line, value.Column, key.Value, entity.ErrParseCityDefinition, "metadata %q must be a string", key.Value)
+			continue
+		}
+		builder.definition.Metadata[key.Value] = value.Value
+	}
+}
+
+// readDocumentCity reads a city node of a world map document
+func readDocumentCity(builder *mapBuilder, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		builder.addIssue(node.Line, node.Column, node.Value, entity.ErrParseCityDefinition, "expected a city with a name and links")
+		return
+	}
+	var nameNode, linksNode *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "name":
+			nameNode = value
+		case "links":
+			linksNode = value
+		default:
+			builder.addIssue(key.Line, key.Column, key.Value, entity.ErrParseCityDefinition, "unknown key %q, expected name or links", key.Value)
+		}
+	}
+	if nameNode == nil {
+		builder.addIssue(node.Line, node.Column, "", entity.ErrEmptyCityName, "missing city name")
+		return
+	}
+	if nameNode.Kind != yaml.ScalarNode {
+		builder.addIssue(nameNode.Line, nameNode.Column, "", entity.ErrParseCityDefinition, "city name must be a string")
+		return
+	}
+	cityDefinition := builder.addCity(nameNode.Value, nameNode.Line, nameNode.Column)
+	if linksNode == nil || isNullNode(linksNode) {
+		return
+	}
+	if linksNode.Kind != yaml.MappingNode {
+		builder.addIssue(linksNode.Line, linksNode.Column, linksNode.Value, entity.ErrParseCityDefinition, "expected a mapping of directions to cities")
+		return
+	}
+	for i := 0; i+1 < len(linksNode.Content); i += 2 {
+		key, value := linksNode.Content[i], linksNode.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			builder.addIssue(value.Line, value.Column, key.Value, entity.ErrParseCityDefinition, "city of direction %q must be a string", key.Value)
+			continue
+		}
+		builder.addLink(cityDefinition, key.Value, value.Value, key.Line, key.Column, fmt.Sprintf("%s: %s", key.Value, value.Value))
+	}
+}
+
+// isNullNode checks if a document node is null
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// offsetPosition converts the offset of a character of a document to its line and column
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,162 @@
+package simulator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_NewMapReader(t *testing.T) {
+	tests := []struct {
+		name, giveFormat, giveFileName string
+		wantReader                     MapReader
+		wantError                      error
+	}{
+		{"Text by default", "", "map.txt", NewTextMapReader("map.txt"), nil},
+		{"JSON from extension", "", "map.JSON", NewJSONMapReader("map.JSON"), nil},
+		{"YAML from extension", "", "map.yml", NewYAMLMapReader("map.yml"), nil},
+		{"Explicit format", YAMLMapFormat, "map.txt", NewYAMLMapReader("map.txt"), nil},
+		{"Unknown format", "xml", "map.xml", nil, entity.ErrUnknownMapFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewMapReader(tt.giveFormat, tt.giveFileName)
+			require.Equal(t, tt.wantError, err)
+			require.Equal(t, tt.wantReader, reader)
+		})
+	}
+}
+
+func Test_DocumentMapReader_Read(t *testing.T) {
+	text := `
+New-York north=Boston west=Chicago
+Boston south=New-York
+`
+	jsonInput := `{
+	"metadata": {"name": "East coast", "author": "tooling"},
+	"cities": [
+		{"name": "New-York", "links": {"north": "Boston", "west": "Chicago"}},
+		{"name": "Boston", "links": {"south": "New-York"}}
+	]
+}`
+	yamlInput := `
+metadata:
+  name: East coast
+  author: tooling
+cities:
+  - name: New-York
+    links:
+      north: Boston
+      west: Chicago
+  - name: Boston
+    links:
+      south: New-York
+`
+
+	wantDefinition, err := NewTextMapReader("").Read(strings.NewReader(text))
+	require.NoError(t, err)
+	wantMetadata := map[string]string{"name": "East coast", "author": "tooling"}
+
+	tests := []struct {
+		name       string
+		giveReader MapReader
+		input      string
+	}{
+		{"JSON", NewJSONMapReader(""), jsonInput},
+		{"YAML", NewYAMLMapReader(""), yamlInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, err := tt.giveReader.Read(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			require.Equal(t, wantMetadata, definition.Metadata)
+			require.Equal(t, wantDefinition.CityNames(), definition.CityNames())
+			require.Equal(t, len(wantDefinition.Cities), len(definition.Cities))
+			for i, cityDefinition := range definition.Cities {
+				require.Equal(t, wantDefinition.Cities[i].Name, cityDefinition.Name)
+				require.Equal(t, len(wantDefinition.Cities[i].Links), len(cityDefinition.Links))
+				for j, linkDefinition := range cityDefinition.Links {
+					require.Equal(t, wantDefinition.Cities[i].Links[j].Direction, linkDefinition.Direction)
+					require.Equal(t, wantDefinition.Cities[i].Links[j].CityTo, linkDefinition.CityTo)
+				}
+			}
+		})
+	}
+
+	t.Run("City names with spaces", func(t *testing.T) {
+		definition, err := NewYAMLMapReader("").Read(strings.NewReader("cities:\n  - name: New York\n    links:\n      north: Boston\n"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"New York", "Boston"}, definition.CityNames())
+		require.Nil(t, definition.Metadata)
+	})
+}
+
+func Test_DocumentMapReader_Read_Issues(t *testing.T) {
+	tests := []struct {
+		name       string
+		giveReader MapReader
+		input      string
+		wantIssues []string
+		wantError  error
+	}{
+		{
+			name:       "Case 1: invalid JSON",
+			giveReader: NewJSONMapReader("map.json"),
+			input:      "{\n  \"cities\": [\n    {\"name\": \"City1\",}\n  ]\n}",
+			wantIssues: []string{`map.json:3:22: invalid JSON: invalid character '}' looking for beginning of object key string`},
+			wantError:  entity.ErrParseCityDefinition,
+		},
+		{
+			name:       "Case 2: invalid YAML",
+			giveReader: NewYAMLMapReader("map.yaml"),
+			input:      "cities: [",
+			wantIssues: []string{`map.yaml: invalid YAML: yaml: line 1: did not find expected node content`},
+			wantError:  entity.ErrParseCityDefinition,
+		},
+		{
+			name:       "Case 3: unknown direction",
+			giveReader: NewYAMLMapReader("map.yaml"),
+			input:      "cities:\n  - name: City1\n    links:\n      up: City2\n",
+			wantIssues: []string{`map.yaml:4:7: unknown direction "up", expected north, east, south or west`},
+			wantError:  entity.ErrUnknownDirection,
+		},
+		{
+			name:       "Case 4: unknown key and missing name",
+			giveReader: NewYAMLMapReader("map.yaml"),
+			input:      "cities:\n  - city: City1\n",
+			wantIssues: []string{
+				`map.yaml:2:5: unknown key "city", expected name or links`,
+				`map.yaml:2:5: missing city name`,
+			},
+			wantError: entity.ErrEmptyCityName,
+		},
+		{
+			name:       "Case 5: self link",
+			giveReader: NewJSONMapReader("map.json"),
+			input:      `{"cities": [{"name": "City1", "links": {"east": "City1"}}]}`,
+			wantIssues: []string{`map.json:1:41: link from city "City1" to itself`},
+			wantError:  entity.ErrLinkSameCity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.giveReader.SetCollectAll(true)
+			_, err := tt.giveReader.Read(strings.NewReader(tt.input))
+			require.ErrorIs(t, err, tt.wantError)
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+			issues := make([]string, 0)
+			for _, issue := range parseErr.Issues {
+				issues = append(issues, issue.String())
+			}
+			require.Equal(t, tt.wantIssues, issues)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// NewMapWriter is a map writer constructor given a map format
+// If the format is empty, it is detected from the extension of the file name (.json, .yaml or .yml), and defaults to text
+func NewMapWriter(format, fileName string) (MapWriter, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(fileName)) {
+		case ".json":
+			format = JSONMapFormat
+		case ".yaml", ".yml":
+			format = YAMLMapFormat
+		default:
+			format = TextMapFormat
+		}
+	}
+	switch format {
+	case TextMapFormat:
+		return NewTextMapWriter(), nil
+	case JSONMapFormat:
+		return NewJSONMapWriter(), nil
+	case YAMLMapFormat:
+		return NewYAMLMapWriter(), nil
+	default:
+		return nil, entity.ErrUnknownMapFormat
+	}
+}
+
+// cityLink is a link from a city
+type cityLink struct {
+	direction entity.Direction
+	cityTo    *entity.City
+}
+
+// aliveCityLinks retrieves the alive cities of a world, in their input order, with their links to alive cities in the directions order
+func aliveCityLinks(ctx context.Context, world WorldStorer) ([]*entity.City, map[*entity.City][]*cityLink, error) {
+	cities, err := world.GetAliveCities(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	alive := make(map[*entity.City]bool, len(cities))
+	for _, city := range cities {
+		alive[city] = true
+	}
+	links := make(map[*entity.City][]*cityLink, len(cities))
+	for _, city := range cities {
+		links[city] = make([]*cityLink, 0)
+		for _, direction := range entity.Directions {
+			cityTo, err := city.GetCityTo(direction)
+			if err != nil {
+				return nil, nil, err
+			}
+			if cityTo != nil && alive[cityTo] {
+				links[city] = append(links[city], &cityLink{direction: direction, cityTo: cityTo})
+			}
+		}
+	}
+	return cities, links, nil
+}
+
+// TextMapWriter is a writer of world maps in the text format
+type TextMapWriter struct{}
+
+var _ MapWriter = (*TextMapWriter)(nil)
+
+// NewTextMapWriter is a text map writer constructor
+func NewTextMapWriter() *TextMapWriter {
+	return &TextMapWriter{}
+}
+
+// Write writes the alive cities of a world in the text format, one city per line in their input order
+// The city names are quoted when needed, and the links to destroyed cities are left out
+func (w *TextMapWriter) Write(ctx context.Context, world WorldStorer, out io.Writer) error {
+	log.Debug("TextMapWriter Write")
+
+	cities, links, err := aliveCityLinks(ctx, world)
+	if err != nil {
+		return err
+	}
+	for _, city := range cities {
+		chunks := []string{entity.QuoteCityName(city.Name)}
+		for _, link := range links[city] {
+			chunks = append(chunks, fmt.Sprintf("%s=%s", link.direction, entity.QuoteCityName(link.cityTo.Name)))
+		}
+		_, err = fmt.Fprintln(out, strings.Join(chunks, " "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapDocument is a world map document
+type mapDocument struct {
+	Metadata map[string]string  `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Cities   []*mapDocumentCity `json:"cities" yaml:"cities"`
+}
+
+// mapDocumentCity is a city of a world map document
+type mapDocumentCity struct {
+	Name  string            `json:"name" yaml:"name"`
+	Links *mapDocumentLinks `json:"links,omitempty" yaml:"links,omitempty"`
+}
+
+// mapDocumentLinks are the links of a city of a world map document, in the directions order
+type mapDocumentLinks struct {
+	North string `json:"north,omitempty" yaml:"north,omitempty"`
+	East  string `json:"east,omitempty" yaml:"east,omitempty"`
+	South string `json:"south,omitempty" yaml:"south,omitempty"`
+	West  string `json:"west,omitempty" yaml:"west,omitempty"`
+}
+
+// DocumentMapWriter is a writer of world maps in a structured document format (JSON or YAML)
+type DocumentMapWriter struct {
+	// Format of the document
+	format string
+
+	// Optional metadata of the map
+	metadata map[string]string
+}
+
+var _ MapWriter = (*DocumentMapWriter)(nil)
+
+// NewJSONMapWriter is a JSON map writer constructor
+func NewJSONMapWriter() *DocumentMapWriter {
+	return &DocumentMapWriter{
+		format: JSONMapFormat,
+	}
+}
+
+// NewYAMLMapWriter is a YAML map writer constructor
+func NewYAMLMapWriter() *DocumentMapWriter {
+	return &DocumentMapWriter{
+		format: YAMLMapFormat,
+	}
+}
+
+// SetMetadata sets the metadata written with the map
+func (w *DocumentMapWriter) SetMetadata(metadata map[string]string) {
+	w.metadata = metadata
+}
+
+// Write writes the alive cities of a world as a document, in their input order
+// The links to destroyed cities are left out
+func (w *DocumentMapWriter) Write(ctx context.Context, world WorldStorer, out io.Writer) error {
+	log.WithFields(log.Fields{
+		"format": w.format,
+	}).Debug("DocumentMapWriter Write")
+
+	cities, links, err := aliveCityLinks(ctx, world)
+	if err != nil {
+		return err
+	}
+	document := &mapDocument{
+		Metadata: w.metadata,
+		Cities:   make([]*mapDocumentCity, 0, len(cities)),
+	}
+	for _, city := range cities {
+		documentCity := &mapDocumentCity{
+			Name: city.Name,
+		}
+		if len(links[city]) > 0 {
+			documentCity.Links = &mapDocumentLinks{}
+		}
+		for _, link := range links[city] {
+			switch link.direction {
+			case entity.North:
+				documentCity.Links.North = link.cityTo.Name
+			case entity.East:
+				documentCity.Links.East = link.cityTo.Name
+			case entity.South:
+				documentCity.Links.South = link.cityTo.Name
+			case entity.West:
+				documentCity.Links.West = link.cityTo.Name
+			}
+		}
+		document.Cities = append(document.Cities, documentCity)
+	}
+
+	switch w.format {
+	case YAMLMapFormat:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		err = encoder.Encode(document)
+		if err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(document)
+	}
+}
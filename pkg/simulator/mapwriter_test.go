@@ -0,0 +1,175 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_NewMapWriter(t *testing.T) {
+	tests := []struct {
+		name, giveFormat, giveFileName string
+		wantWriter                     MapWriter
+		wantError                      error
+	}{
+		{"Text by default", "", "map.txt", NewTextMapWriter(), nil},
+		{"JSON from extension", "", "map.json", NewJSONMapWriter(), nil},
+		{"YAML from extension", "", "map.yaml", NewYAMLMapWriter(), nil},
+		{"Explicit format", JSONMapFormat, "map.txt", NewJSONMapWriter(), nil},
+		{"Unknown format", "xml", "map.xml", nil, entity.ErrUnknownMapFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, err := NewMapWriter(tt.giveFormat, tt.giveFileName)
+			require.Equal(t, tt.wantError, err)
+			require.Equal(t, tt.wantWriter, writer)
+		})
+	}
+}
+
+func Test_MapWriter_Write(t *testing.T) {
+	ctx := context.Background()
+
+	world := loadTestWorld(t, `
+"New York" north=Boston west=Chicago south="Rio de Janeiro"
+Boston south="New York" east=Paris
+Chicago east="New York"
+"The \"Big\" Apple"
+`)
+	paris, err := world.GetCity(ctx, "Paris")
+	require.NoError(t, err)
+	require.NoError(t, world.DestroyCity(ctx, paris))
+
+	tests := []struct {
+		name       string
+		giveWriter MapWriter
+		want       string
+	}{
+		{
+			name:       "Text",
+			giveWriter: NewTextMapWriter(),
+			want: `"New York" north=Boston south="Rio de Janeiro" west=Chicago
+Boston south="New York"
+Chicago east="New York"
+"Rio de Janeiro"
+"The \"Big\" Apple"
+`,
+		},
+		{
+			name:       "YAML",
+			giveWriter: NewYAMLMapWriter(),
+			want: `cities:
+  - name: New York
+    links:
+      north: Boston
+      south: Rio de Janeiro
+      west: Chicago
+  - name: Boston
+    links:
+      south: New York
+  - name: Chicago
+    links:
+      east: New York
+  - name: Rio de Janeiro
+  - name: The "Big" Apple
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := tt.giveWriter.Write(ctx, world, out)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+
+	t.Run("JSON with metadata", func(t *testing.T) {
+		writer := NewJSONMapWriter()
+		writer.SetMetadata(map[string]string{"name": "Americas"})
+		out := &bytes.Buffer{}
+		err := writer.Write(ctx, world, out)
+		require.NoError(t, err)
+		definition, err := NewJSONMapReader("").Read(out)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"name": "Americas"}, definition.Metadata)
+	})
+}
+
+func Test_MapWriter_Write_ControlCharacters(t *testing.T) {
+	ctx := context.Background()
+
+	// The city names of a JSON map may contain control characters, that are escaped in the text format
+	definition, err := NewJSONMapReader("").Read(strings.NewReader(`{"cities": [{"name": "New\nYork", "links": {"north": "Carriage\rReturn"}}, {"name": "Bell\u0007"}]}`))
+	require.NoError(t, err)
+	world := NewWorld()
+	require.NoError(t, definition.LoadToWorld(ctx, world))
+
+	out := &bytes.Buffer{}
+	require.NoError(t, NewTextMapWriter().Write(ctx, world, out))
+	require.Equal(t, "\"New\\nYork\" north=\"Carriage\\rReturn\"\n\"Carriage\\rReturn\"\n\"Bell\\u0007\"\n", out.String())
+
+	readDefinition, err := NewTextMapReader("").Read(out)
+	require.NoError(t, err)
+	readWorld := NewWorld()
+	require.NoError(t, readDefinition.LoadToWorld(ctx, readWorld))
+	require.Equal(t, worldCities(t, world), worldCities(t, readWorld))
+	require.Equal(t, []string{"New\nYork", "Carriage\rReturn", "Bell\a"}, readDefinition.CityNames())
+}
+
+// worldCities retrieves the text representation of the alive cities of a world by city name
+func worldCities(t *testing.T, world WorldStorer) map[string]string {
+	cities, err := world.GetAliveCities(context.Background())
+	require.NoError(t, err)
+	worldCities := make(map[string]string, len(cities))
+	for _, city := range cities {
+		worldCities[city.Name] = city.String()
+	}
+	return worldCities
+}
+
+func Test_MapWriter_RoundTrip(t *testing.T) {
+	input := `
+Paris north=Brussels west=London east=Berlin south=Barcelona
+Berlin north=Stockholm east=Warsaw
+Barcelona north=Paris east=Roma
+Athens
+London west=Paris
+Stockholm north=Warsaw
+Warsaw south=Geneva west=Berlin north=Stockholm
+"Rio de Janeiro" north=Roma east="New York"
+Roma west=Barcelona north=Geneva
+`
+
+	for _, format := range []string{TextMapFormat, JSONMapFormat, YAMLMapFormat} {
+		for seed := int64(0); seed < 10; seed++ {
+			ctx := context.Background()
+
+			// Simulate a few steps so that some cities are destroyed
+			world := NewWorld()
+			s := NewSimulationEngine(6, 5, world, NewRandomSeeded(seed), strings.NewReader(input), nil)
+			require.NoError(t, s.Run(ctx))
+
+			// Write then read back the world
+			writer, err := NewMapWriter(format, "")
+			require.NoError(t, err)
+			out := &bytes.Buffer{}
+			require.NoError(t, writer.Write(ctx, world, out))
+			reader, err := NewMapReader(format, "")
+			require.NoError(t, err)
+			definition, err := reader.Read(out)
+			require.NoError(t, err, format)
+			readWorld := NewWorld()
+			require.NoError(t, definition.LoadToWorld(ctx, readWorld))
+
+			require.Equal(t, worldCities(t, world), worldCities(t, readWorld), format)
+		}
+	}
+}
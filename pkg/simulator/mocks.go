@@ -75,10 +75,10 @@ func (w *WorldStorerMock) TrapAlien(ctx context.Context, alien *entity.Alien) er
 	return args.Error(0)
 }
 
-// GetAlienAtCity retrieves the alien at a given city
-func (w *WorldStorerMock) GetAlienAtCity(ctx context.Context, city *entity.City) (*entity.Alien, error) {
+// GetAliensAtCity retrieves the untrapped aliens at a given city
+func (w *WorldStorerMock) GetAliensAtCity(ctx context.Context, city *entity.City) ([]*entity.Alien, error) {
 	args := w.Called(ctx, city)
-	return args.Get(0).(*entity.Alien), args.Error(1)
+	return args.Get(0).([]*entity.Alien), args.Error(1)
 }
 
 // GetUntrappedAliens retrieves the list of untrapped aliens
@@ -124,6 +124,18 @@ func (s *SimulatorMock) Finalize(ctx context.Context) error {
 	return args.Error(0)
 }
 
+// Outcome retrieves the outcome of the simulation
+func (s *SimulatorMock) Outcome(ctx context.Context) (*Outcome, error) {
+	args := s.Called(ctx)
+	return args.Get(0).(*Outcome), args.Error(1)
+}
+
+// Checkpoint takes a snapshot of the complete state of the simulation
+func (s *SimulatorMock) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	args := s.Called(ctx)
+	return args.Get(0).(*Checkpoint), args.Error(1)
+}
+
 // RandomerMock mocks a Randomer
 type RandomerMock struct {
 	mock.Mock
@@ -136,3 +148,16 @@ func (r *RandomerMock) GetRandomInt(n int) (int, error) {
 	args := r.Called(n)
 	return args.Int(0), args.Error(1)
 }
+
+// ObserverMock mocks an Observer
+type ObserverMock struct {
+	mock.Mock
+}
+
+var _ Observer = (*ObserverMock)(nil)
+
+// Notify notifies the observer of a simulation event
+func (o *ObserverMock) Notify(ctx context.Context, event *Event) error {
+	args := o.Called(ctx, event)
+	return args.Error(0)
+}
@@ -0,0 +1,244 @@
+package simulator
+
+import (
+	"context"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+const (
+	// UniformMovementName is the name of the uniform movement strategy
+	UniformMovementName = "uniform"
+
+	// StayMovementName is the name of the stay in place movement strategy
+	StayMovementName = "stay"
+
+	// UnvisitedMovementName is the name of the prefer unvisited cities movement strategy
+	UnvisitedMovementName = "unvisited"
+
+	// LazyMovementName is the name of the lazy movement strategy
+	LazyMovementName = "lazy"
+
+	// SeekMovementName is the name of the seek nearest alien movement strategy
+	SeekMovementName = "seek"
+)
+
+// probabilityResolution is the resolution used to draw a random event given its probability
+const probabilityResolution = 1000000
+
+// NewMovementStrategy creates a movement strategy given its name
+// The stay probability is only used by the stay in place movement strategy
+func NewMovementStrategy(name string, stayProbability float64) (MovementStrategy, error) {
+	switch name {
+	case UniformMovementName, "":
+		return NewUniformMovement(), nil
+	case StayMovementName:
+		return NewStayMovement(stayProbability)
+	case UnvisitedMovementName:
+		return NewUnvisitedMovement(), nil
+	case LazyMovementName:
+		return NewLazyMovement(), nil
+	case SeekMovementName:
+		return NewSeekMovement(), nil
+	default:
+		return nil, entity.ErrUnknownMovementStrategy
+	}
+}
+
+// UniformMovement is a movement strategy that picks uniformly one of the available links
+type UniformMovement struct{}
+
+var _ MovementStrategy = (*UniformMovement)(nil)
+
+// NewUniformMovement is a uniform movement strategy constructor
+func NewUniformMovement() *UniformMovement {
+	return &UniformMovement{}
+}
+
+// NextCity chooses the next city of an alien
+func (m *UniformMovement) NextCity(ctx context.Context, alien *entity.Alien, world WorldStorer, random Randomer) (*entity.City, error) {
+	return pickCity(availableCities(alien.City), random)
+}
+
+// String implements Stringer interface for a movement strategy
+func (m *UniformMovement) String() string {
+	return UniformMovementName
+}
+
+// StayMovement is a movement strategy where an alien stays in place with a given probability
+// Otherwise it picks uniformly one of the available links
+type StayMovement struct {
+	// Probability to stay in place
+	stayProbability float64
+}
+
+var _ MovementStrategy = (*StayMovement)(nil)
+
+// NewStayMovement is a stay in place movement strategy constructor
+func NewStayMovement(stayProbability float64) (*StayMovement, error) {
+	if stayProbability < 0 || stayProbability > 1 {
+		return nil, entity.ErrInvalidProbability
+	}
+	return &StayMovement{
+		stayProbability: stayProbability,
+	}, nil
+}
+
+// StayProbability retrieves the probability to stay in place
+func (m *StayMovement) StayProbability() float64 {
+	return m.stayProbability
+}
+
+// NextCity chooses the next city of an alien
+func (m *StayMovement) NextCity(ctx context.Context, alien *entity.Alien, world WorldStorer, random Randomer) (*entity.City, error) {
+	r, err := random.GetRandomInt(probabilityResolution)
+	if err != nil {
+		return nil, err
+	}
+	if r < int(m.stayProbability*probabilityResolution) {
+		return nil, nil
+	}
+	return pickCity(availableCities(alien.City), random)
+}
+
+// String implements Stringer interface for a movement strategy
+func (m *StayMovement) String() string {
+	return StayMovementName
+}
+
+// UnvisitedMovement is a movement strategy that picks uniformly one of the cities not visited yet by an alien
+// If all the neighbour cities have been visited, it picks uniformly one of the available links
+type UnvisitedMovement struct{}
+
+var _ MovementStrategy = (*UnvisitedMovement)(nil)
+
+// NewUnvisitedMovement is a prefer unvisited cities movement strategy constructor
+func NewUnvisitedMovement() *UnvisitedMovement {
+	return &UnvisitedMovement{}
+}
+
+// NextCity chooses the next city of an alien
+func (m *UnvisitedMovement) NextCity(ctx context.Context, alien *entity.Alien, world WorldStorer, random Randomer) (*entity.City, error) {
+	cities := availableCities(alien.City)
+	unvisitedCities := make([]*entity.City, 0, len(cities))
+	for _, city := range cities {
+		if !alien.HasVisited(city) {
+			unvisitedCities = append(unvisitedCities, city)
+		}
+	}
+	if len(unvisitedCities) > 0 {
+		return pickCity(unvisitedCities, random)
+	}
+	return pickCity(cities, random)
+}
+
+// String implements Stringer interface for a movement strategy
+func (m *UnvisitedMovement) String() string {
+	return UnvisitedMovementName
+}
+
+// LazyMovement is a movement strategy where an alien never goes back to the city it comes from
+// It picks uniformly one of the other available links, or stays in place if there is none
+type LazyMovement struct{}
+
+var _ MovementStrategy = (*LazyMovement)(nil)
+
+// NewLazyMovement is a lazy movement strategy constructor
+func NewLazyMovement() *LazyMovement {
+	return &LazyMovement{}
+}
+
+// NextCity chooses the next city of an alien
+func (m *LazyMovement) NextCity(ctx context.Context, alien *entity.Alien, world WorldStorer, random Randomer) (*entity.City, error) {
+	cities := availableCities(alien.City)
+	forwardCities := make([]*entity.City, 0, len(cities))
+	for _, city := range cities {
+		if city != alien.PreviousCity {
+			forwardCities = append(forwardCities, city)
+		}
+	}
+	return pickCity(forwardCities, random)
+}
+
+// String implements Stringer interface for a movement strategy
+func (m *LazyMovement) String() string {
+	return LazyMovementName
+}
+
+// SeekMovement is a movement strategy where an alien moves towards the nearest other alien
+// Distances are computed along the links, ties are broken with the directions order
+// If no other alien can be reached, it picks uniformly one of the available links
+type SeekMovement struct{}
+
+var _ MovementStrategy = (*SeekMovement)(nil)
+
+// NewSeekMovement is a seek nearest alien movement strategy constructor
+func NewSeekMovement() *SeekMovement {
+	return &SeekMovement{}
+}
+
+// NextCity chooses the next city of an alien
+func (m *SeekMovement) NextCity(ctx context.Context, alien *entity.Alien, world WorldStorer, random Randomer) (*entity.City, error) {
+	// Breadth first search from the alien city, remembering the first city of the path
+	firstCities := map[*entity.City]*entity.City{alien.City: nil}
+	queue := make([]*entity.City, 0)
+	for _, city := range availableCities(alien.City) {
+		if _, found := firstCities[city]; !found {
+			firstCities[city] = city
+			queue = append(queue, city)
+		}
+	}
+	for len(queue) > 0 {
+		city := queue[0]
+		queue = queue[1:]
+		aliensAtCity, err := world.GetAliensAtCity(ctx, city)
+		if err != nil {
+			return nil, err
+		}
+		for _, alienAtCity := range aliensAtCity {
+			if alienAtCity != alien {
+				return firstCities[city], nil
+			}
+		}
+		for _, cityTo := range availableCities(city) {
+			if _, found := firstCities[cityTo]; !found {
+				firstCities[cityTo] = firstCities[city]
+				queue = append(queue, cityTo)
+			}
+		}
+	}
+
+	return pickCity(availableCities(alien.City), random)
+}
+
+// String implements Stringer interface for a movement strategy
+func (m *SeekMovement) String() string {
+	return SeekMovementName
+}
+
+// availableCities retrieves the cities linked to a city, in the directions order
+func availableCities(city *entity.City) []*entity.City {
+	directions := city.GetAvailableDirections()
+	cities := make([]*entity.City, 0, len(directions))
+	for _, direction := range directions {
+		cityTo, err := city.GetCityTo(direction)
+		if err != nil {
+			continue
+		}
+		cities = append(cities, cityTo)
+	}
+	return cities
+}
+
+// pickCity picks uniformly one of the cities
+// Returns nil if there is no city to pick
+func pickCity(cities []*entity.City, random Randomer) (*entity.City, error) {
+	if len(cities) == 0 {
+		return nil, nil
+	}
+	r, err := random.GetRandomInt(len(cities))
+	if err != nil {
+		return nil, err
+	}
+	return cities[r], nil
+}
@@ -0,0 +1,114 @@
+package simulator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_NewMovementStrategy(t *testing.T) {
+	tests := []struct {
+		name, giveName      string
+		giveStayProbability float64
+		wantName            string
+		wantError           error
+	}{
+		{"Default", "", 0, UniformMovementName, nil},
+		{"Uniform", UniformMovementName, 0, UniformMovementName, nil},
+		{"Stay", StayMovementName, 0.5, StayMovementName, nil},
+		{"Stay with invalid probability", StayMovementName, 1.5, "", entity.ErrInvalidProbability},
+		{"Unvisited", UnvisitedMovementName, 0, UnvisitedMovementName, nil},
+		{"Lazy", LazyMovementName, 0, LazyMovementName, nil},
+		{"Seek", SeekMovementName, 0, SeekMovementName, nil},
+		{"Unknown", "teleport", 0, "", entity.ErrUnknownMovementStrategy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movement, err := NewMovementStrategy(tt.giveName, tt.giveStayProbability)
+			require.ErrorIs(t, err, tt.wantError)
+			if tt.wantError == nil {
+				require.Equal(t, tt.wantName, movement.String())
+			}
+		})
+	}
+}
+
+func Test_MovementStrategy_NextCity(t *testing.T) {
+	ctx := context.Background()
+
+	// CityN
+	//   |
+	// CityW - City - CityE - CityEE
+	//   |
+	// CityS
+	world := NewWorld()
+	city, _ := world.AddCity(ctx, "City")
+	cityN, _ := world.AddCity(ctx, "CityN")
+	cityE, _ := world.AddCity(ctx, "CityE")
+	cityEE, _ := world.AddCity(ctx, "CityEE")
+	cityW, _ := world.AddCity(ctx, "CityW")
+	cityS, _ := world.AddCity(ctx, "CityS")
+	require.NoError(t, world.AddLink(ctx, city, cityE, entity.East))
+	require.NoError(t, world.AddLink(ctx, city, cityW, entity.West))
+	require.NoError(t, world.AddLink(ctx, cityE, city, entity.West))
+	require.NoError(t, world.AddLink(ctx, cityE, cityEE, entity.East))
+	require.NoError(t, world.AddLink(ctx, cityW, city, entity.East))
+	require.NoError(t, world.AddLink(ctx, cityW, cityN, entity.North))
+	require.NoError(t, world.AddLink(ctx, cityW, cityS, entity.South))
+
+	// Alien1 comes from CityE and has already visited CityW
+	alien1, _ := world.AddAlien(ctx, 1)
+	require.NoError(t, world.MoveAlien(ctx, alien1, cityW))
+	require.NoError(t, world.MoveAlien(ctx, alien1, cityE))
+	require.NoError(t, world.MoveAlien(ctx, alien1, city))
+
+	// Alien2 is in CityEE
+	alien2, _ := world.AddAlien(ctx, 2)
+	require.NoError(t, world.MoveAlien(ctx, alien2, cityEE))
+
+	// Alien3 comes from City
+	alien3, _ := world.AddAlien(ctx, 3)
+	require.NoError(t, world.MoveAlien(ctx, alien3, city))
+	require.NoError(t, world.MoveAlien(ctx, alien3, cityW))
+
+	stayMovement, err := NewStayMovement(0.25)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		giveMovement   MovementStrategy
+		giveAlien      *entity.Alien
+		giveRandomInts []int
+		wantRandomNs   []int
+		want           *entity.City
+	}{
+		{"Uniform picks the first link", NewUniformMovement(), alien1, []int{0}, []int{2}, cityE},
+		{"Uniform picks the last link", NewUniformMovement(), alien1, []int{1}, []int{2}, cityW},
+		{"Uniform without link", NewUniformMovement(), alien2, []int{}, []int{}, nil},
+		{"Stay in place", stayMovement, alien1, []int{249999}, []int{probabilityResolution}, nil},
+		{"Stay does not stay", stayMovement, alien1, []int{250000, 1}, []int{probabilityResolution, 2}, cityW},
+		{"Unvisited has no unvisited neighbour", NewUnvisitedMovement(), alien1, []int{1}, []int{2}, cityW},
+		{"Unvisited prefers unvisited neighbours", NewUnvisitedMovement(), alien3, []int{1}, []int{2}, cityS},
+		{"Lazy does not backtrack", NewLazyMovement(), alien1, []int{0}, []int{1}, cityW},
+		{"Seek moves towards the nearest alien", NewSeekMovement(), alien1, []int{}, []int{}, cityW},
+		{"Seek moves towards the only reachable alien", NewSeekMovement(), alien3, []int{}, []int{}, city},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			randomerMock := &RandomerMock{}
+			for i, n := range tt.wantRandomNs {
+				randomerMock.On("GetRandomInt", n).Return(tt.giveRandomInts[i], nil).Once()
+			}
+			defer randomerMock.AssertExpectations(t)
+
+			got, err := tt.giveMovement.NextCity(ctx, tt.giveAlien, world, randomerMock)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
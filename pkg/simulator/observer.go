@@ -0,0 +1,135 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// TextObserver is an observer that writes a human readable output of the simulation
+type TextObserver struct {
+	// Output writer
+	out io.Writer
+}
+
+var _ Observer = (*TextObserver)(nil)
+
+// NewTextObserver is a text observer constructor
+func NewTextObserver(out io.Writer) *TextObserver {
+	return &TextObserver{
+		out: out,
+	}
+}
+
+// Notify notifies the observer of a simulation event
+func (o *TextObserver) Notify(ctx context.Context, event *Event) error {
+	switch event.Type {
+	case CityDestroyed:
+		_, err := fmt.Fprintf(o.out, "%s has been destroyed by %s\n", event.City.Name, formatAliens(event.Aliens))
+		return err
+	case SimulationFinished:
+		_, err := fmt.Fprintln(o.out, "")
+		if err != nil {
+			return err
+		}
+		for _, city := range event.Cities {
+			_, err = fmt.Fprintln(o.out, city)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatAliens formats a list of aliens as "Alien #1, Alien #2 and Alien #3"
+func formatAliens(aliens []*entity.Alien) string {
+	names := make([]string, 0, len(aliens))
+	for _, alien := range aliens {
+		names = append(names, alien.String())
+	}
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return fmt.Sprintf("%s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+// JSONEvent is the JSON representation of a simulation event
+type JSONEvent struct {
+	// Type of the event
+	Type string `json:"type"`
+
+	// Step during which the event occurred
+	Step uint `json:"step"`
+
+	// Identifiers of the aliens involved in the event
+	Aliens []int `json:"aliens,omitempty"`
+
+	// Origin city of a move
+	From string `json:"from,omitempty"`
+
+	// Destination city of a move
+	To string `json:"to,omitempty"`
+
+	// City where the event occurred (spawned, trapped or destroyed city)
+	City string `json:"city,omitempty"`
+
+	// Alive cities at the end of the simulation, always set when the simulation is finished even if all the cities have been destroyed
+	Cities *[]string `json:"cities,omitempty"`
+
+	// Reason why the simulation ended
+	Reason string `json:"reason,omitempty"`
+}
+
+// NewJSONEvent is a JSON event constructor
+func NewJSONEvent(event *Event) *JSONEvent {
+	jsonEvent := &JSONEvent{
+		Type: event.Type.String(),
+		Step: event.Step,
+	}
+	for _, alien := range event.Aliens {
+		jsonEvent.Aliens = append(jsonEvent.Aliens, alien.AlienID)
+	}
+	switch event.Type {
+	case AlienMoved:
+		jsonEvent.From = event.CityFrom.Name
+		jsonEvent.To = event.City.Name
+	default:
+		if event.City != nil {
+			jsonEvent.City = event.City.Name
+		}
+	}
+	if event.Type == SimulationFinished {
+		cities := make([]string, 0, len(event.Cities))
+		for _, city := range event.Cities {
+			cities = append(cities, city.Name)
+		}
+		jsonEvent.Cities = &cities
+		jsonEvent.Reason = event.Reason.String()
+	}
+	return jsonEvent
+}
+
+// JSONLinesObserver is an observer that writes one JSON object per event
+type JSONLinesObserver struct {
+	// JSON encoder
+	encoder *json.Encoder
+}
+
+var _ Observer = (*JSONLinesObserver)(nil)
+
+// NewJSONLinesObserver is a JSON Lines observer constructor
+func NewJSONLinesObserver(out io.Writer) *JSONLinesObserver {
+	return &JSONLinesObserver{
+		encoder: json.NewEncoder(out),
+	}
+}
+
+// Notify notifies the observer of a simulation event
+func (o *JSONLinesObserver) Notify(ctx context.Context, event *Event) error {
+	return o.encoder.Encode(NewJSONEvent(event))
+}
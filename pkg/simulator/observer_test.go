@@ -0,0 +1,94 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TextObserver(t *testing.T) {
+	alien1 := entity.NewAlien(1)
+	alien2 := entity.NewAlien(2)
+	alien3 := entity.NewAlien(3)
+	city1 := entity.NewCity("City1")
+	city2 := entity.NewCity("City2")
+
+	tests := []struct {
+		name      string
+		giveEvent *Event
+		wantOut   string
+	}{
+		{
+			name:      "Alien moved",
+			giveEvent: &Event{Type: AlienMoved, Step: 1, Aliens: []*entity.Alien{alien1}, CityFrom: city1, City: city2},
+			wantOut:   "",
+		},
+		{
+			name:      "City destroyed by two aliens",
+			giveEvent: &Event{Type: CityDestroyed, Step: 1, Aliens: []*entity.Alien{alien2, alien1}, City: city1},
+			wantOut:   "City1 has been destroyed by Alien #2 and Alien #1\n",
+		},
+		{
+			name:      "City destroyed by three aliens",
+			giveEvent: &Event{Type: CityDestroyed, Step: 1, Aliens: []*entity.Alien{alien1, alien2, alien3}, City: city2},
+			wantOut:   "City2 has been destroyed by Alien #1, Alien #2 and Alien #3\n",
+		},
+		{
+			name:      "Simulation finished",
+			giveEvent: &Event{Type: SimulationFinished, Step: 5, Cities: []*entity.City{city1, city2}},
+			wantOut:   "\nCity1\nCity2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			o := NewTextObserver(out)
+			err := o.Notify(context.Background(), tt.giveEvent)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
+
+func Test_JSONLinesObserver(t *testing.T) {
+	alien1 := entity.NewAlien(1)
+	alien2 := entity.NewAlien(2)
+	city1 := entity.NewCity("City1")
+	city2 := entity.NewCity("City2")
+
+	ctx := context.Background()
+	out := &bytes.Buffer{}
+	o := NewJSONLinesObserver(out)
+
+	events := []*Event{
+		{Type: AlienSpawned, Step: 0, Aliens: []*entity.Alien{alien1}, City: city1},
+		{Type: StepStarted, Step: 1},
+		{Type: AlienMoved, Step: 1, Aliens: []*entity.Alien{alien1}, CityFrom: city1, City: city2},
+		{Type: AlienTrapped, Step: 1, Aliens: []*entity.Alien{alien2}, City: city2},
+		{Type: CityDestroyed, Step: 1, Aliens: []*entity.Alien{alien1, alien2}, City: city2},
+		{Type: StepEnded, Step: 1},
+		{Type: SimulationFinished, Step: 1, Aliens: []*entity.Alien{alien1}, Cities: []*entity.City{city1}, Reason: MaxStepsReached},
+		{Type: SimulationFinished, Step: 1, Cities: []*entity.City{}, Reason: AllCitiesDestroyed},
+		{Type: SimulationFinished, Step: 1, Reason: AllCitiesDestroyed},
+	}
+	for _, event := range events {
+		err := o.Notify(ctx, event)
+		require.NoError(t, err)
+	}
+
+	want := `{"type":"AlienSpawned","step":0,"aliens":[1],"city":"City1"}
+{"type":"StepStarted","step":1}
+{"type":"AlienMoved","step":1,"aliens":[1],"from":"City1","to":"City2"}
+{"type":"AlienTrapped","step":1,"aliens":[2],"city":"City2"}
+{"type":"CityDestroyed","step":1,"aliens":[1,2],"city":"City2"}
+{"type":"StepEnded","step":1}
+{"type":"SimulationFinished","step":1,"aliens":[1],"cities":["City1"],"reason":"max_steps_reached"}
+{"type":"SimulationFinished","step":1,"cities":[],"reason":"all_cities_destroyed"}
+{"type":"SimulationFinished","step":1,"cities":[],"reason":"all_cities_destroyed"}
+`
+	require.Equal(t, want, out.String())
+}
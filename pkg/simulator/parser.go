@@ -0,0 +1,356 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// MapDefinition is a parsed world map
+type MapDefinition struct {
+	// Name of the map file (may be empty)
+	File string
+
+	// Optional metadata of the map (nil if none)
+	Metadata map[string]string
+
+	// City definitions in their order of appearance
+	Cities []*CityDefinition
+
+	// Problems found that do not prevent the map from being loaded
+	Warnings []*ParseIssue
+}
+
+// CityDefinition is the definition of a city and its links on a line of a world map
+type CityDefinition struct {
+	// City name
+	Name string
+
+	// Position of the definition
+	Line, Column int
+
+	// Links from the city
+	Links []*LinkDefinition
+}
+
+// LinkDefinition is the definition of a link from a city to another city
+type LinkDefinition struct {
+	// Direction of the link
+	Direction entity.Direction
+
+	// Destination city name
+	CityTo string
+
+	// Position of the definition
+	Line, Column int
+}
+
+// ParseIssue is a problem found while parsing a world map
+type ParseIssue struct {
+	// Name of the map file (may be empty)
+	File string
+
+	// Position of the problem
+	Line, Column int
+
+	// Token where the problem is
+	Token string
+
+	// Human readable reason
+	Reason string
+
+	// Underlying error
+	Err error
+
+	// Is the problem a warning, that does not prevent the map from being loaded
+	Warning bool
+}
+
+// String implements Stringer interface for a parse issue
+// The position is omitted when it is unknown
+func (pi *ParseIssue) String() string {
+	reason := pi.Reason
+	if pi.Warning {
+		reason = "warning: " + reason
+	}
+	positions := make([]string, 0, 2)
+	if pi.File != "" {
+		positions = append(positions, pi.File)
+	}
+	if pi.Line > 0 {
+		positions = append(positions, fmt.Sprintf("%d:%d", pi.Line, pi.Column))
+	}
+	if len(positions) == 0 {
+		return reason
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(positions, ":"), reason)
+}
+
+// ParseError is the error triggered when a world map can't be parsed
+// It matches ErrParseCityDefinition and the underlying errors of its issues with errors.Is
+type ParseError struct {
+	// Problems found
+	Issues []*ParseIssue
+}
+
+// Error implements error interface for a parse error
+func (pe *ParseError) Error() string {
+	issues := make([]string, 0, len(pe.Issues))
+	for _, issue := range pe.Issues {
+		issues = append(issues, issue.String())
+	}
+	return fmt.Sprintf("%s: %s", entity.ErrParseCityDefinition, strings.Join(issues, "; "))
+}
+
+// Is checks if a parse error matches a target error
+func (pe *ParseError) Is(target error) bool {
+	if target == entity.ErrParseCityDefinition {
+		return true
+	}
+	for _, issue := range pe.Issues {
+		if issue.Err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// mapBuilder builds a map definition and collects its problems, whatever the format of the world map
+type mapBuilder struct {
+	// Map definition being built
+	definition *MapDefinition
+
+	// Problems found
+	issues []*ParseIssue
+
+	// Links already defined from each city, to detect conflicting links
+	definedLinks map[string]map[entity.Direction]*LinkDefinition
+
+	// Directions already used by the current city definition, to detect duplicate directions
+	cityDirections map[entity.Direction]bool
+}
+
+// newMapBuilder is a map builder constructor
+func newMapBuilder(fileName string) *mapBuilder {
+	return &mapBuilder{
+		definition: &MapDefinition{
+			File:     fileName,
+			Cities:   make([]*CityDefinition, 0),
+			Warnings: make([]*ParseIssue, 0),
+		},
+		issues:       make([]*ParseIssue, 0),
+		definedLinks: make(map[string]map[entity.Direction]*LinkDefinition),
+	}
+}
+
+// addIssue records a problem
+func (b *mapBuilder) addIssue(line, column int, token string, err error, reason string, args ...interface{}) {
+	b.issues = append(b.issues, &ParseIssue{
+		File:   b.definition.File,
+		Line:   line,
+		Column: column,
+		Token:  token,
+		Reason: fmt.Sprintf(reason, args...),
+		Err:    err,
+	})
+}
+
+// addWarning records a problem that does not prevent the map from being loaded
+func (b *mapBuilder) addWarning(line, column int, token string, err error, reason string, args ...interface{}) {
+	b.definition.Warnings = append(b.definition.Warnings, &ParseIssue{
+		File:    b.definition.File,
+		Line:    line,
+		Column:  column,
+		Token:   token,
+		Reason:  fmt.Sprintf(reason, args...),
+		Err:     err,
+		Warning: true,
+	})
+}
+
+// addCity starts the definition of a city
+func (b *mapBuilder) addCity(cityName string, line, column int) *CityDefinition {
+	cityDefinition := &CityDefinition{
+		Name:   cityName,
+		Line:   line,
+		Column: column,
+		Links:  make([]*LinkDefinition, 0),
+	}
+	if cityName == "" {
+		b.addIssue(line, column, cityName, entity.ErrEmptyCityName, "empty city name")
+	}
+	if _, found := b.definedLinks[cityName]; !found {
+		b.definedLinks[cityName] = make(map[entity.Direction]*LinkDefinition)
+	}
+	b.cityDirections = make(map[entity.Direction]bool)
+	b.definition.Cities = append(b.definition.Cities, cityDefinition)
+	return cityDefinition
+}
+
+// addLink adds a link to the definition of the current city
+func (b *mapBuilder) addLink(cityDefinition *CityDefinition, directionName, cityToName string, line, column int, token string) {
+	direction, err := entity.ParseDirection(directionName)
+	if err != nil {
+		b.addIssue(line, column, token, entity.ErrUnknownDirection, "unknown direction %q, expected north, east, south or west", directionName)
+		return
+	}
+	if cityToName == "" {
+		b.addIssue(line, column, token, entity.ErrEmptyCityName, "empty city name for direction %s of city %q", direction, cityDefinition.Name)
+		return
+	}
+	linkDefinition := &LinkDefinition{
+		Direction: direction,
+		CityTo:    cityToName,
+		Line:      line,
+		Column:    column,
+	}
+	if b.cityDirections[direction] {
+		b.addIssue(line, column, token, entity.ErrAlreadyExistsLink, "duplicate direction %s for city %q", direction, cityDefinition.Name)
+		return
+	}
+	b.cityDirections[direction] = true
+	if linkDefinition.CityTo == cityDefinition.Name {
+		b.addIssue(line, column, token, entity.ErrLinkSameCity, "link from city %q to itself", cityDefinition.Name)
+		return
+	}
+	if definedLink, found := b.definedLinks[cityDefinition.Name][direction]; found {
+		if definedLink.CityTo != linkDefinition.CityTo {
+			b.addIssue(line, column, token, entity.ErrAlreadyExistsLink, "conflicting link %s of city %q to %q, already linked to %q at line %d", direction, cityDefinition.Name, linkDefinition.CityTo, definedLink.CityTo, definedLink.Line)
+			return
+		}
+		// The same link declared again is harmless, the world ignores it
+		b.addWarning(line, column, token, entity.ErrAlreadyExistsLink, "duplicate link %s of city %q to %q, already declared at line %d", direction, cityDefinition.Name, linkDefinition.CityTo, definedLink.Line)
+	}
+	b.definedLinks[cityDefinition.Name][direction] = linkDefinition
+	cityDefinition.Links = append(cityDefinition.Links, linkDefinition)
+}
+
+// build returns the map definition, or a *ParseError describing the problems found
+// Only the first problem is reported, unless collectAll is set
+func (b *mapBuilder) build(collectAll bool) (*MapDefinition, error) {
+	if len(b.issues) == 0 {
+		return b.definition, nil
+	}
+	if !collectAll {
+		return nil, &ParseError{Issues: b.issues[:1]}
+	}
+	return nil, &ParseError{Issues: b.issues}
+}
+
+// CityNames returns the names of all the cities of the map definition in their order of appearance
+func (d *MapDefinition) CityNames() []string {
+	cityNames := make([]string, 0)
+	registered := make(map[string]bool)
+	register := func(cityName string) {
+		if !registered[cityName] {
+			registered[cityName] = true
+			cityNames = append(cityNames, cityName)
+		}
+	}
+	for _, cityDefinition := range d.Cities {
+		register(cityDefinition.Name)
+		for _, linkDefinition := range cityDefinition.Links {
+			register(linkDefinition.CityTo)
+		}
+	}
+	return cityNames
+}
+
+// TotalLinks returns the number of links of the map definition
+func (d *MapDefinition) TotalLinks() int {
+	totalLinks := 0
+	for _, cityDefinition := range d.Cities {
+		totalLinks += len(cityDefinition.Links)
+	}
+	return totalLinks
+}
+
+// LoadToWorld saves the cities and links of the map definition to a world
+func (d *MapDefinition) LoadToWorld(ctx context.Context, world WorldStorer) error {
+	// Helper function
+	registerCity := func(cityName string) (*entity.City, error) {
+		city, err := world.GetCity(ctx, cityName)
+		if err != nil {
+			return city, err
+		}
+		if city == nil {
+			city, err = world.AddCity(ctx, cityName)
+			if err != nil {
+				return city, err
+			}
+		}
+		return city, nil
+	}
+
+	for _, cityDefinition := range d.Cities {
+		cityFrom, err := registerCity(cityDefinition.Name)
+		if err != nil {
+			return err
+		}
+		for _, linkDefinition := range cityDefinition.Links {
+			cityTo, err := registerCity(linkDefinition.CityTo)
+			if err != nil {
+				return err
+			}
+			err = world.AddLink(ctx, cityFrom, cityTo, linkDefinition.Direction)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddReverseLinks treats every link of the map definition as a two-way road and saves its reverse link to a world
+// The cities and links of the map definition must already be loaded in the world
+// It returns a *ParseError describing the links whose opposite direction is already linked to another city
+func (d *MapDefinition) AddReverseLinks(ctx context.Context, world WorldStorer) error {
+	parseErr := &ParseError{
+		Issues: make([]*ParseIssue, 0),
+	}
+	for _, cityDefinition := range d.Cities {
+		cityFrom, err := world.GetCity(ctx, cityDefinition.Name)
+		if err != nil {
+			return err
+		}
+		for _, linkDefinition := range cityDefinition.Links {
+			cityTo, err := world.GetCity(ctx, linkDefinition.CityTo)
+			if err != nil {
+				return err
+			}
+			if cityFrom == nil || cityTo == nil {
+				return entity.ErrUnknownCity
+			}
+			reverseDirection := linkDefinition.Direction.Opposite()
+			reverseCityTo, err := cityTo.GetCityTo(reverseDirection)
+			if err != nil {
+				return err
+			}
+			switch reverseCityTo {
+			case cityFrom:
+			case nil:
+				err = world.AddLink(ctx, cityTo, cityFrom, reverseDirection)
+				if err != nil {
+					return err
+				}
+			default:
+				from, to := entity.QuoteCityName(cityFrom.Name), entity.QuoteCityName(cityTo.Name)
+				parseErr.Issues = append(parseErr.Issues, &ParseIssue{
+					File:   d.File,
+					Line:   linkDefinition.Line,
+					Column: linkDefinition.Column,
+					Token:  fmt.Sprintf("%s=%s", linkDefinition.Direction, linkDefinition.CityTo),
+					Reason: fmt.Sprintf("reverse link %s %s=%s of %s %s=%s conflicts with %s %s=%s", to, reverseDirection, from, from, linkDefinition.Direction, to, to, reverseDirection, entity.QuoteCityName(reverseCityTo.Name)),
+					Err:    entity.ErrReverseLinkConflict,
+				})
+			}
+		}
+	}
+	if len(parseErr.Issues) > 0 {
+		return parseErr
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_TextMapReader_Read(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		giveCollectAll bool
+		wantIssues     []string
+		wantErrors     []error
+		wantWarnings   []string
+	}{
+		{
+			name: "Case 1: valid map",
+			input: `
+City1 north=City2	east=City3
+  City2   south=City1
+`,
+			wantIssues:   []string{},
+			wantWarnings: []string{},
+		},
+		{
+			name:       "Case 2: unknown direction",
+			input:      "City1 up=City2",
+			wantIssues: []string{`map.txt:1:7: unknown direction "up", expected north, east, south or west`},
+			wantErrors: []error{entity.ErrParseCityDefinition, entity.ErrUnknownDirection},
+		},
+		{
+			name:       "Case 3: malformed link",
+			input:      "City1 north=City2=City3",
+			wantIssues: []string{`map.txt:1:7: malformed link "north=City2=City3", expected direction=city`},
+			wantErrors: []error{entity.ErrParseCityDefinition},
+		},
+		{
+			name:       "Case 4: malformed city name",
+			input:      "north=City2 south=City3",
+			wantIssues: []string{`map.txt:1:1: malformed city name "north=City2", a line must start with a city name`},
+			wantErrors: []error{entity.ErrParseCityDefinition},
+		},
+		{
+			name:       "Case 5: duplicate direction",
+			input:      "City1 north=City2 north=City3",
+			wantIssues: []string{`map.txt:1:19: duplicate direction north for city "City1"`},
+			wantErrors: []error{entity.ErrAlreadyExistsLink},
+		},
+		{
+			name:       "Case 6: self link",
+			input:      "City1 east=City1",
+			wantIssues: []string{`map.txt:1:7: link from city "City1" to itself`},
+			wantErrors: []error{entity.ErrLinkSameCity},
+		},
+		{
+			name:       "Case 7: conflicting link",
+			input:      "City1 north=City2\nCity1 north=City3",
+			wantIssues: []string{`map.txt:2:7: conflicting link north of city "City1" to "City3", already linked to "City2" at line 1`},
+			wantErrors: []error{entity.ErrAlreadyExistsLink},
+		},
+		{
+			name:         "Case 8: duplicate link",
+			input:        "City1 north=City2\nCity1 north=City2\nCity2 south=City1",
+			wantIssues:   []string{},
+			wantWarnings: []string{`map.txt:2:7: warning: duplicate link north of city "City1" to "City2", already declared at line 1`},
+		},
+		{
+			name:       "Case 9: first problem only",
+			input:      "City1 up=City2 north=\nCity2 east=City2",
+			wantIssues: []string{`map.txt:1:7: unknown direction "up", expected north, east, south or west`},
+			wantErrors: []error{entity.ErrUnknownDirection},
+		},
+		{
+			name:           "Case 10: all the problems",
+			input:          "City1 up=City2 north=\nCity2 east=City2",
+			giveCollectAll: true,
+			wantIssues: []string{
+				`map.txt:1:7: unknown direction "up", expected north, east, south or west`,
+				`map.txt:1:16: malformed link "north=", expected direction=city`,
+				`map.txt:2:7: link from city "City2" to itself`,
+			},
+			wantErrors: []error{entity.ErrUnknownDirection, entity.ErrParseCityDefinition, entity.ErrLinkSameCity},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewTextMapReader("map.txt")
+			parser.SetCollectAll(tt.giveCollectAll)
+			definition, err := parser.Read(strings.NewReader(tt.input))
+			if len(tt.wantIssues) == 0 {
+				require.NoError(t, err)
+				require.NotNil(t, definition)
+				warnings := make([]string, 0)
+				for _, warning := range definition.Warnings {
+					warnings = append(warnings, warning.String())
+				}
+				require.Equal(t, tt.wantWarnings, warnings)
+				return
+			}
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+			issues := make([]string, 0)
+			for _, issue := range parseErr.Issues {
+				issues = append(issues, issue.String())
+			}
+			require.Equal(t, tt.wantIssues, issues)
+			for _, wantError := range tt.wantErrors {
+				require.ErrorIs(t, err, wantError)
+			}
+			require.False(t, errors.Is(err, entity.ErrUnknownCity))
+		})
+	}
+}
+
+func Test_MapDefinition(t *testing.T) {
+	ctx := context.Background()
+
+	input := `
+City1 north=City2 east=City3
+City2 south=City1 east=City4
+City1 north=City2
+`
+	definition, err := NewTextMapReader("").Read(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Equal(t, []string{"City1", "City2", "City3", "City4"}, definition.CityNames())
+	require.Equal(t, 5, definition.TotalLinks())
+	require.Len(t, definition.Warnings, 1)
+
+	world := NewWorld()
+	err = definition.LoadToWorld(ctx, world)
+	require.NoError(t, err)
+	cities, err := world.GetAliveCities(ctx)
+	require.NoError(t, err)
+	cityNames := make([]string, 0)
+	for _, city := range cities {
+		cityNames = append(cityNames, city.Name)
+	}
+	require.Equal(t, definition.CityNames(), cityNames)
+	require.Equal(t, "City2", cities[0].North.Name)
+	require.Equal(t, "City4", cities[1].East.Name)
+}
+
+func Test_MapDefinition_AddReverseLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantIssues []string
+		wantLinks  map[string]string
+	}{
+		{
+			name:       "Case 1: reverse links added",
+			input:      "Barcelona north=Paris\nParis south=Barcelona\nBerlin north=Stockholm east=Warsaw\n",
+			wantIssues: []string{},
+			wantLinks: map[string]string{
+				"Barcelona": "Barcelona north=Paris",
+				"Paris":     "Paris south=Barcelona",
+				"Berlin":    "Berlin north=Stockholm east=Warsaw",
+				"Stockholm": "Stockholm south=Berlin",
+				"Warsaw":    "Warsaw west=Berlin",
+			},
+		},
+		{
+			name:  "Case 2: reverse link conflict",
+			input: "Paris north=Brussels\nBrussels south=Amsterdam\n",
+			wantIssues: []string{
+				`map.txt:1:7: reverse link Brussels south=Paris of Paris north=Brussels conflicts with Brussels south=Amsterdam`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			definition, err := NewTextMapReader("map.txt").Read(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			world := NewWorld()
+			require.NoError(t, definition.LoadToWorld(ctx, world))
+			err = definition.AddReverseLinks(ctx, world)
+			if len(tt.wantIssues) > 0 {
+				require.ErrorIs(t, err, entity.ErrReverseLinkConflict)
+				var parseErr *ParseError
+				require.True(t, errors.As(err, &parseErr))
+				issues := make([]string, 0)
+				for _, issue := range parseErr.Issues {
+					issues = append(issues, issue.String())
+				}
+				require.Equal(t, tt.wantIssues, issues)
+				return
+			}
+			require.NoError(t, err)
+			for cityName, wantLinks := range tt.wantLinks {
+				city, err := world.GetCity(ctx, cityName)
+				require.NoError(t, err)
+				require.Equal(t, wantLinks, city.String())
+			}
+		})
+	}
+}
+
+func Test_TextMapReader_Read_QuotedNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantCityNames []string
+		wantIssues    []string
+	}{
+		{
+			name:          "Case 1: quoted names",
+			input:         `"New York" north="Rio de Janeiro"   west=Chicago`,
+			wantCityNames: []string{"New York", "Rio de Janeiro", "Chicago"},
+		},
+		{
+			name:          "Case 2: escaped quotes and equal signs",
+			input:         `"The \"Big\" Apple" east="A=B"` + "\t" + `south="C\\D"`,
+			wantCityNames: []string{`The "Big" Apple`, "A=B", `C\D`},
+		},
+		{
+			name:       "Case 3: unterminated quote",
+			input:      `"New York north=Boston`,
+			wantIssues: []string{`map.txt:1:1: unterminated quoted name`},
+		},
+		{
+			name:       "Case 4: empty quoted name",
+			input:      `"" north=Boston`,
+			wantIssues: []string{`map.txt:1:1: empty city name`},
+		},
+		{
+			name:          "Case 5: escaped control characters",
+			input:         `"New\nYork" north="Carriage\rReturn" east="Tab\tBell\u0007" west="C:\Temp\u12"`,
+			wantCityNames: []string{"New\nYork", "Carriage\rReturn", "Tab\tBell\a", `C:\Temp\u12`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewTextMapReader("map.txt")
+			reader.SetCollectAll(true)
+			definition, err := reader.Read(strings.NewReader(tt.input))
+			if len(tt.wantIssues) > 0 {
+				var parseErr *ParseError
+				require.True(t, errors.As(err, &parseErr))
+				issues := make([]string, 0)
+				for _, issue := range parseErr.Issues {
+					issues = append(issues, issue.String())
+				}
+				require.Equal(t, tt.wantIssues, issues)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCityNames, definition.CityNames())
+		})
+	}
+}
+
+func Test_TextMapReader_Read_CityString(t *testing.T) {
+	ctx := context.Background()
+
+	// The text representation of the cities can be read back
+	city := entity.NewCity(`The "Big" Apple`)
+	city.North = entity.NewCity("Rio de Janeiro")
+	city.East = entity.NewCity(`C:\Cities`)
+	definition, err := NewTextMapReader("").Read(strings.NewReader(city.String()))
+	require.NoError(t, err)
+	world := NewWorld()
+	require.NoError(t, definition.LoadToWorld(ctx, world))
+	got, err := world.GetCity(ctx, city.Name)
+	require.NoError(t, err)
+	require.Equal(t, city.String(), got.String())
+}
@@ -34,3 +34,88 @@ func (rs *RandomSimple) GetRandomInt(n int) (int, error) {
 
 	return r, nil
 }
+
+// RandomSeeded is a deterministic random integer generator
+// It relies on its own source initialized with an explicit seed, so that a run can be replayed
+// Important: it does not rely on crypto safe random generator
+type RandomSeeded struct {
+	// Seed used to initialize the source
+	seed int64
+
+	// Source that counts the values drawn
+	source *countingSource
+
+	// Random generator
+	rand *rand.Rand
+}
+
+var _ Randomer = (*RandomSeeded)(nil)
+
+// NewRandomSeeded is a seeded random generator constructor
+func NewRandomSeeded(seed int64) *RandomSeeded {
+	source := &countingSource{
+		source: rand.NewSource(seed),
+	}
+	return &RandomSeeded{
+		seed:   seed,
+		source: source,
+		rand:   rand.New(source),
+	}
+}
+
+// RestoreRandomSeeded is a seeded random generator constructor that restores the state of a generator
+// given its seed and the number of values already drawn from its source
+func RestoreRandomSeeded(seed int64, draws uint64) *RandomSeeded {
+	rs := NewRandomSeeded(seed)
+	for rs.source.draws < draws {
+		rs.source.Int63()
+	}
+	return rs
+}
+
+// Seed retrieves the seed of the random generator
+func (rs *RandomSeeded) Seed() int64 {
+	return rs.seed
+}
+
+// Draws retrieves the number of values already drawn from the source of the random generator
+func (rs *RandomSeeded) Draws() uint64 {
+	return rs.source.draws
+}
+
+// GetRandomInt retrieves a random integer between 0 and n-1 given n
+// Returns an error if n <= 0
+func (rs *RandomSeeded) GetRandomInt(n int) (int, error) {
+	r := 0
+	if n <= 0 {
+		return r, entity.ErrRandomOutOfBounds
+	}
+	r = rs.rand.Intn(n)
+	log.WithFields(log.Fields{
+		"n":      n,
+		"random": r,
+		"seed":   rs.seed,
+	}).Debug("GetRandomInt")
+	return r, nil
+}
+
+// countingSource is a random source that counts the values drawn
+type countingSource struct {
+	// Underlying source
+	source rand.Source
+
+	// Number of values drawn
+	draws uint64
+}
+
+// Int63 draws a value from the underlying source
+func (cs *countingSource) Int63() int64 {
+	cs.draws++
+	return cs.source.Int63()
+}
+
+// Seed seeds the underlying source
+func (cs *countingSource) Seed(seed int64) {
+	cs.draws = 0
+	cs.source.Seed(seed)
+}
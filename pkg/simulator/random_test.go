@@ -59,3 +59,73 @@ func Test_RandomSimple(t *testing.T) {
 		})
 	}
 }
+
+func Test_RandomSeeded(t *testing.T) {
+	tests := []struct {
+		giveN     int
+		wantError error
+	}{
+		{
+			giveN:     -1,
+			wantError: entity.ErrRandomOutOfBounds,
+		},
+		{
+			giveN:     0,
+			wantError: entity.ErrRandomOutOfBounds,
+		},
+		{
+			giveN:     1,
+			wantError: nil,
+		},
+		{
+			giveN:     279,
+			wantError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := fmt.Sprintf("Input: %d", tt.giveN)
+		t.Run(testName, func(t *testing.T) {
+			rs := NewRandomSeeded(42)
+			require.Equal(t, int64(42), rs.Seed())
+			r, err := rs.GetRandomInt(tt.giveN)
+			require.Equal(t, tt.wantError, err)
+			switch err {
+			case nil:
+				require.GreaterOrEqual(t, r, 0)
+				require.Less(t, r, tt.giveN)
+			default:
+				require.Equal(t, 0, r)
+			}
+		})
+	}
+
+	t.Run("Same seed replays same sequence", func(t *testing.T) {
+		rs1 := NewRandomSeeded(1234)
+		rs2 := NewRandomSeeded(1234)
+		for i := 0; i < 100; i++ {
+			r1, err := rs1.GetRandomInt(1000)
+			require.NoError(t, err)
+			r2, err := rs2.GetRandomInt(1000)
+			require.NoError(t, err)
+			require.Equal(t, r1, r2)
+		}
+	})
+
+	t.Run("Restored generator replays the rest of the sequence", func(t *testing.T) {
+		rs := NewRandomSeeded(1234)
+		for i := 0; i < 50; i++ {
+			_, err := rs.GetRandomInt(279)
+			require.NoError(t, err)
+		}
+		rsRestored := RestoreRandomSeeded(rs.Seed(), rs.Draws())
+		require.Equal(t, rs.Draws(), rsRestored.Draws())
+		for i := 0; i < 50; i++ {
+			r1, err := rs.GetRandomInt(279)
+			require.NoError(t, err)
+			r2, err := rsRestored.GetRandomInt(279)
+			require.NoError(t, err)
+			require.Equal(t, r1, r2)
+		}
+	})
+}
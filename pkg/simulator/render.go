@@ -0,0 +1,293 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// ASCIIRenderer is a renderer of a world on its inferred grid layout as ASCII art
+// The alive cities show the ids of their untrapped aliens, e.g. Paris[1,3], and the destroyed cities are prefixed with #
+// The roads are drawn with - and | when they are two-way, and with arrows when they are one-way
+type ASCIIRenderer struct {
+	// Layout of the cities
+	layout *Layout
+
+	// Destroyed city names
+	destroyedCities map[string]bool
+}
+
+// NewASCIIRenderer is an ASCII renderer constructor
+func NewASCIIRenderer(layout *Layout) *ASCIIRenderer {
+	return &ASCIIRenderer{
+		layout:          layout,
+		destroyedCities: make(map[string]bool),
+	}
+}
+
+// AddDestroyedCity records a destroyed city so that it is still drawn
+func (r *ASCIIRenderer) AddDestroyedCity(cityName string) {
+	r.destroyedCities[cityName] = true
+}
+
+// Render renders the current state of a world
+func (r *ASCIIRenderer) Render(ctx context.Context, world WorldStorer, out io.Writer) error {
+	width, height := r.layout.Size()
+
+	// Labels of the cities by position, and widths of the columns
+	labels := make(map[Position]string)
+	alive := make(map[Position]*entity.City)
+	columnWidths := make([]int, width)
+	for x := range columnWidths {
+		columnWidths[x] = 1
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			position := Position{X: x, Y: y}
+			cityName := r.layout.CityAt(position)
+			if cityName == "" {
+				continue
+			}
+			city, err := world.GetCity(ctx, cityName)
+			if err != nil {
+				return err
+			}
+			switch {
+			case city != nil:
+				label, err := r.cityLabel(ctx, world, city)
+				if err != nil {
+					return err
+				}
+				labels[position] = label
+				alive[position] = city
+			case r.destroyedCities[cityName]:
+				labels[position] = "#" + cityName
+			default:
+				continue
+			}
+			columnWidths[x] = maxInt(columnWidths[x], utf8.RuneCountInString(labels[position]))
+		}
+	}
+
+	lines := make([]string, 0, 2*height)
+	for y := 0; y < height; y++ {
+		// Cities and east-west roads
+		line := &strings.Builder{}
+		for x := 0; x < width; x++ {
+			position := Position{X: x, Y: y}
+			label := labels[position]
+			line.WriteString(label)
+			padding := columnWidths[x] - utf8.RuneCountInString(label)
+			if x == width-1 {
+				break
+			}
+			road, err := roadBetween(alive[position], alive[position.move(entity.East)], entity.East)
+			if err != nil {
+				return err
+			}
+			switch road {
+			case twoWayRoad:
+				line.WriteString(strings.Repeat("-", padding+3))
+			case forwardRoad:
+				line.WriteString(strings.Repeat("-", padding+2) + ">")
+			case backwardRoad:
+				line.WriteString("<" + strings.Repeat("-", padding+2))
+			default:
+				line.WriteString(strings.Repeat(" ", padding+3))
+			}
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+		if y == height-1 {
+			break
+		}
+
+		// North-south roads
+		line = &strings.Builder{}
+		for x := 0; x < width; x++ {
+			position := Position{X: x, Y: y}
+			road, err := roadBetween(alive[position], alive[position.move(entity.South)], entity.South)
+			if err != nil {
+				return err
+			}
+			switch road {
+			case twoWayRoad:
+				line.WriteString("|")
+			case forwardRoad:
+				line.WriteString("v")
+			case backwardRoad:
+				line.WriteString("^")
+			default:
+				line.WriteString(" ")
+			}
+			line.WriteString(strings.Repeat(" ", columnWidths[x]+2))
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+
+	for _, line := range lines {
+		_, err := fmt.Fprintln(out, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cityLabel computes the label of an alive city, with the ids of its untrapped aliens
+func (r *ASCIIRenderer) cityLabel(ctx context.Context, world WorldStorer, city *entity.City) (string, error) {
+	aliens, err := world.GetAliensAtCity(ctx, city)
+	if err != nil {
+		return "", err
+	}
+	if len(aliens) == 0 {
+		return city.Name, nil
+	}
+	alienIDs := make([]string, 0, len(aliens))
+	for _, alien := range aliens {
+		alienIDs = append(alienIDs, strconv.Itoa(alien.AlienID))
+	}
+	return fmt.Sprintf("%s[%s]", city.Name, strings.Join(alienIDs, ",")), nil
+}
+
+// road represents the road drawn between two neighbour cities
+type road int
+
+const (
+	// noRoad is drawn when the cities are not linked
+	noRoad road = iota
+
+	// twoWayRoad is drawn when the cities are linked both ways
+	twoWayRoad
+
+	// forwardRoad is drawn when only the first city is linked to the second one
+	forwardRoad
+
+	// backwardRoad is drawn when only the second city is linked to the first one
+	backwardRoad
+)
+
+// roadBetween computes the road between two neighbour cities given the direction of the second one
+func roadBetween(city, neighbour *entity.City, direction entity.Direction) (road, error) {
+	if city == nil || neighbour == nil {
+		return noRoad, nil
+	}
+	cityTo, err := city.GetCityTo(direction)
+	if err != nil {
+		return noRoad, err
+	}
+	cityFrom, err := neighbour.GetCityTo(direction.Opposite())
+	if err != nil {
+		return noRoad, err
+	}
+	switch {
+	case cityTo == neighbour && cityFrom == city:
+		return twoWayRoad, nil
+	case cityTo == neighbour:
+		return forwardRoad, nil
+	case cityFrom == city:
+		return backwardRoad, nil
+	default:
+		return noRoad, nil
+	}
+}
+
+// RenderMode represents when the world is rendered during a simulation
+type RenderMode int
+
+const (
+	// RenderFinal render mode renders the world when the simulation is finished
+	RenderFinal RenderMode = iota
+
+	// RenderStep render mode renders the world at the end of every step and when the simulation is finished
+	RenderStep
+)
+
+// String implements Stringer interface for a render mode
+func (m RenderMode) String() string {
+	switch m {
+	case RenderFinal:
+		return "final"
+	case RenderStep:
+		return "step"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRenderMode parses a render mode from its name
+func ParseRenderMode(name string) (RenderMode, error) {
+	for _, mode := range []RenderMode{RenderFinal, RenderStep} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return RenderFinal, entity.ErrUnknownRenderMode
+}
+
+// RenderObserver is an observer that renders the world as ASCII art
+// The layout is inferred when the first event is notified, before any city is destroyed
+type RenderObserver struct {
+	// World of the simulation
+	world WorldStorer
+
+	// When the world is rendered
+	mode RenderMode
+
+	// ASCII renderer (nil until the layout is inferred)
+	renderer *ASCIIRenderer
+
+	// Output writer
+	out io.Writer
+}
+
+var _ Observer = (*RenderObserver)(nil)
+
+// NewRenderObserver is a render observer constructor
+func NewRenderObserver(world WorldStorer, mode RenderMode, out io.Writer) *RenderObserver {
+	return &RenderObserver{
+		world: world,
+		mode:  mode,
+		out:   out,
+	}
+}
+
+// Notify notifies the observer of a simulation event
+func (o *RenderObserver) Notify(ctx context.Context, event *Event) error {
+	if o.renderer == nil {
+		layout, err := NewLayout(ctx, o.world)
+		if err != nil {
+			return err
+		}
+		for _, conflict := range layout.Conflicts() {
+			log.Warnf("layout conflict: %s", conflict)
+		}
+		o.renderer = NewASCIIRenderer(layout)
+	}
+	switch event.Type {
+	case CityDestroyed:
+		o.renderer.AddDestroyedCity(event.City.Name)
+	case StepEnded:
+		if o.mode != RenderStep {
+			return nil
+		}
+		_, err := fmt.Fprintf(o.out, "\nStep %d\n", event.Step)
+		if err != nil {
+			return err
+		}
+		return o.renderer.Render(ctx, o.world, o.out)
+	case SimulationFinished:
+		_, err := fmt.Fprintln(o.out, "")
+		if err != nil {
+			return err
+		}
+		return o.renderer.Render(ctx, o.world, o.out)
+	}
+	return nil
+}
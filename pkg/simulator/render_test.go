@@ -0,0 +1,113 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_ParseRenderMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveName  string
+		wantMode  RenderMode
+		wantError error
+	}{
+		{"Final", "final", RenderFinal, nil},
+		{"Step", "step", RenderStep, nil},
+		{"Unknown", "always", RenderFinal, entity.ErrUnknownRenderMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := ParseRenderMode(tt.giveName)
+			require.Equal(t, tt.wantError, err)
+			require.Equal(t, tt.wantMode, mode)
+		})
+	}
+}
+
+func Test_ASCIIRenderer_Render(t *testing.T) {
+	ctx := context.Background()
+
+	world := loadTestWorld(t, `
+Paris north=Brussels west=London east=Berlin south=Barcelona
+Brussels south=Paris
+London east=Paris
+Berlin west=Paris north=Stockholm
+Barcelona east=Roma
+Stockholm
+Roma west=Barcelona
+Athens
+`)
+	layout, err := NewLayout(ctx, world)
+	require.NoError(t, err)
+	renderer := NewASCIIRenderer(layout)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, renderer.Render(ctx, world, out))
+	require.Equal(t, `         Brussels    Stockholm       Athens
+         |           ^
+London---Paris-------Berlin
+         v
+         Barcelona---Roma
+`, out.String())
+
+	// Aliens are shown in their city, and Berlin is destroyed
+	paris, err := world.GetCity(ctx, "Paris")
+	require.NoError(t, err)
+	roma, err := world.GetCity(ctx, "Roma")
+	require.NoError(t, err)
+	berlin, err := world.GetCity(ctx, "Berlin")
+	require.NoError(t, err)
+	for alienID, city := range []*entity.City{paris, paris, roma} {
+		alien, err := world.AddAlien(ctx, alienID+1)
+		require.NoError(t, err)
+		require.NoError(t, world.MoveAlien(ctx, alien, city))
+	}
+	require.NoError(t, world.DestroyCity(ctx, berlin))
+	renderer.AddDestroyedCity(berlin.Name)
+
+	out = &bytes.Buffer{}
+	require.NoError(t, renderer.Render(ctx, world, out))
+	require.Equal(t, `         Brussels     Stockholm       Athens
+         |
+London---Paris[1,2]   #Berlin
+         v
+         Barcelona----Roma[3]
+`, out.String())
+}
+
+func Test_RenderObserver(t *testing.T) {
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name       string
+		giveMode   RenderMode
+		wantRender int
+	}{
+		{"Final", RenderFinal, 1},
+		{"Step", RenderStep, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := NewWorld()
+			out := &bytes.Buffer{}
+			s := NewSimulationEngine(1, 2, world, NewRandomSeeded(42), strings.NewReader(input), nil)
+			s.AddObserver(NewRenderObserver(world, tt.giveMode, out))
+			require.NoError(t, s.Run(context.Background()))
+			require.Equal(t, tt.wantRender, strings.Count(out.String(), "City3"))
+			require.Equal(t, tt.wantRender-1, strings.Count(out.String(), "\nStep "))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// Report is a structured end of run report of a simulation
+type Report struct {
+	// Number of steps executed
+	Steps uint `json:"steps" yaml:"steps"`
+
+	// Reason why the simulation ended
+	TerminationReason string `json:"termination_reason" yaml:"termination_reason"`
+
+	// Cities that survived the invasion
+	SurvivingCities []*ReportCity `json:"surviving_cities" yaml:"surviving_cities"`
+
+	// Cities destroyed during the invasion
+	DestroyedCities []*ReportDestroyedCity `json:"destroyed_cities" yaml:"destroyed_cities"`
+
+	// Final position of the untrapped aliens
+	UntrappedAliens []*ReportAlien `json:"untrapped_aliens" yaml:"untrapped_aliens"`
+}
+
+// ReportCity is a surviving city of a report
+type ReportCity struct {
+	// City name
+	Name string `json:"name" yaml:"name"`
+
+	// Remaining links from the city
+	Links []*ReportLink `json:"links" yaml:"links"`
+}
+
+// ReportLink is a link from a city of a report
+type ReportLink struct {
+	// Direction of the link
+	Direction string `json:"direction" yaml:"direction"`
+
+	// Destination city of the link
+	City string `json:"city" yaml:"city"`
+}
+
+// ReportDestroyedCity is a destroyed city of a report
+type ReportDestroyedCity struct {
+	// City name
+	Name string `json:"name" yaml:"name"`
+
+	// Step during which the city was destroyed
+	Step uint `json:"step" yaml:"step"`
+
+	// Identifiers of the aliens that destroyed the city
+	Aliens []int `json:"aliens" yaml:"aliens"`
+}
+
+// ReportAlien is an untrapped alien of a report
+type ReportAlien struct {
+	// Alien identifier
+	AlienID int `json:"id" yaml:"id"`
+
+	// City where the alien is
+	City string `json:"city" yaml:"city"`
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteYAML writes the report as YAML
+func (r *Report) WriteYAML(out io.Writer) error {
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	err := encoder.Encode(r)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// ReportObserver is an observer that builds the end of run report of a simulation
+type ReportObserver struct {
+	// Report being built
+	report *Report
+
+	// Destroyed cities recorded during the simulation
+	destroyedCities []*ReportDestroyedCity
+}
+
+var _ Observer = (*ReportObserver)(nil)
+
+// NewReportObserver is a report observer constructor
+func NewReportObserver() *ReportObserver {
+	return &ReportObserver{
+		destroyedCities: make([]*ReportDestroyedCity, 0),
+	}
+}
+
+// NewReportObserverFromCheckpoint is a report observer constructor for a simulation resumed from a checkpoint
+// The cities destroyed before the checkpoint are part of the report
+func NewReportObserverFromCheckpoint(checkpoint *Checkpoint) *ReportObserver {
+	o := NewReportObserver()
+	o.destroyedCities = append(o.destroyedCities, checkpoint.DestroyedCities...)
+	return o
+}
+
+// Report retrieves the report once the simulation is finished
+// Returns nil if the simulation is not finished yet
+func (o *ReportObserver) Report() *Report {
+	return o.report
+}
+
+// Notify notifies the observer of a simulation event
+func (o *ReportObserver) Notify(ctx context.Context, event *Event) error {
+	switch event.Type {
+	case CityDestroyed:
+		destroyedCity := &ReportDestroyedCity{
+			Name:   event.City.Name,
+			Step:   event.Step,
+			Aliens: make([]int, 0, len(event.Aliens)),
+		}
+		for _, alien := range event.Aliens {
+			destroyedCity.Aliens = append(destroyedCity.Aliens, alien.AlienID)
+		}
+		o.destroyedCities = append(o.destroyedCities, destroyedCity)
+	case SimulationFinished:
+		o.report = o.newReport(event.Step, event.Reason, event.Cities, event.Aliens)
+	}
+	return nil
+}
+
+// Snapshot builds a report of the current state of a world, while the simulation is running or once it is finished
+func (o *ReportObserver) Snapshot(ctx context.Context, world WorldStorer, outcome *Outcome) (*Report, error) {
+	cities, err := world.GetAliveCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliens, err := world.GetUntrappedAliens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.newReport(outcome.Steps, outcome.Reason, cities, aliens), nil
+}
+
+// newReport creates a report from the alive cities and the untrapped aliens of a world
+func (o *ReportObserver) newReport(steps uint, reason TerminationReason, cities []*entity.City, aliens []*entity.Alien) *Report {
+	report := &Report{
+		Steps:             steps,
+		TerminationReason: reason.String(),
+		SurvivingCities:   make([]*ReportCity, 0, len(cities)),
+		DestroyedCities:   append(make([]*ReportDestroyedCity, 0, len(o.destroyedCities)), o.destroyedCities...),
+		UntrappedAliens:   make([]*ReportAlien, 0, len(aliens)),
+	}
+	for _, city := range cities {
+		report.SurvivingCities = append(report.SurvivingCities, newReportCity(city))
+	}
+	for _, alien := range aliens {
+		reportAlien := &ReportAlien{
+			AlienID: alien.AlienID,
+		}
+		if alien.City != nil {
+			reportAlien.City = alien.City.Name
+		}
+		report.UntrappedAliens = append(report.UntrappedAliens, reportAlien)
+	}
+	return report
+}
+
+// newReportCity creates a report city from a city
+func newReportCity(city *entity.City) *ReportCity {
+	reportCity := &ReportCity{
+		Name:  city.Name,
+		Links: make([]*ReportLink, 0),
+	}
+	for _, direction := range city.GetAvailableDirections() {
+		cityTo, err := city.GetCityTo(direction)
+		if err != nil {
+			continue
+		}
+		reportCity.Links = append(reportCity.Links, &ReportLink{
+			Direction: direction.String(),
+			City:      cityTo.Name,
+		})
+	}
+	return reportCity
+}
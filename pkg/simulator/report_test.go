@@ -0,0 +1,134 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReportObserver(t *testing.T) {
+	ctx := context.Background()
+
+	alien1 := entity.NewAlien(1)
+	alien2 := entity.NewAlien(2)
+	alien3 := entity.NewAlien(3)
+	city1 := entity.NewCity("City1")
+	city2 := entity.NewCity("City2")
+	city3 := entity.NewCity("City3")
+	err := city1.SetCityTo(city3, entity.East)
+	require.NoError(t, err)
+	alien3.City = city3
+
+	o := NewReportObserver()
+	require.Nil(t, o.Report())
+
+	events := []*Event{
+		{Type: StepStarted, Step: 1},
+		{Type: CityDestroyed, Step: 1, Aliens: []*entity.Alien{alien2, alien1}, City: city2},
+		{Type: StepEnded, Step: 1},
+		{Type: SimulationFinished, Step: 5, Aliens: []*entity.Alien{alien3}, Cities: []*entity.City{city1, city3}, Reason: MaxStepsReached},
+	}
+	for _, event := range events {
+		err = o.Notify(ctx, event)
+		require.NoError(t, err)
+	}
+
+	report := o.Report()
+	require.Equal(t, &Report{
+		Steps:             5,
+		TerminationReason: "max_steps_reached",
+		SurvivingCities: []*ReportCity{
+			{Name: "City1", Links: []*ReportLink{{Direction: "east", City: "City3"}}},
+			{Name: "City3", Links: []*ReportLink{}},
+		},
+		DestroyedCities: []*ReportDestroyedCity{
+			{Name: "City2", Step: 1, Aliens: []int{2, 1}},
+		},
+		UntrappedAliens: []*ReportAlien{
+			{AlienID: 3, City: "City3"},
+		},
+	}, report)
+
+	t.Run("JSON", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := report.WriteJSON(out)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"steps": 5,
+			"termination_reason": "max_steps_reached",
+			"surviving_cities": [
+				{"name": "City1", "links": [{"direction": "east", "city": "City3"}]},
+				{"name": "City3", "links": []}
+			],
+			"destroyed_cities": [{"name": "City2", "step": 1, "aliens": [2, 1]}],
+			"untrapped_aliens": [{"id": 3, "city": "City3"}]
+		}`, out.String())
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		err := report.WriteYAML(out)
+		require.NoError(t, err)
+		require.YAMLEq(t, `
+steps: 5
+termination_reason: max_steps_reached
+surviving_cities:
+  - name: City1
+    links:
+      - direction: east
+        city: City3
+  - name: City3
+    links: []
+destroyed_cities:
+  - name: City2
+    step: 1
+    aliens: [2, 1]
+untrapped_aliens:
+  - id: 3
+    city: City3
+`, out.String())
+	})
+}
+
+func Test_ReportObserver_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	world := loadTestWorld(t, `
+City1 east=City2
+City2 west=City1 east=City3
+City3 west=City2
+`)
+	city2, err := world.GetCity(ctx, "City2")
+	require.NoError(t, err)
+	city3, err := world.GetCity(ctx, "City3")
+	require.NoError(t, err)
+	alien1, err := world.AddAlien(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, world.MoveAlien(ctx, alien1, city3))
+
+	o := NewReportObserver()
+	err = o.Notify(ctx, &Event{Type: CityDestroyed, Step: 2, Aliens: []*entity.Alien{entity.NewAlien(2), entity.NewAlien(3)}, City: city2})
+	require.NoError(t, err)
+	require.NoError(t, world.DestroyCity(ctx, city2))
+
+	report, err := o.Snapshot(ctx, world, &Outcome{Steps: 3, Reason: NotTerminated})
+	require.NoError(t, err)
+	require.Equal(t, &Report{
+		Steps:             3,
+		TerminationReason: "not_terminated",
+		SurvivingCities: []*ReportCity{
+			{Name: "City1", Links: []*ReportLink{}},
+			{Name: "City3", Links: []*ReportLink{}},
+		},
+		DestroyedCities: []*ReportDestroyedCity{
+			{Name: "City2", Step: 2, Aliens: []int{2, 3}},
+		},
+		UntrappedAliens: []*ReportAlien{
+			{AlienID: 1, City: "City3"},
+		},
+	}, report)
+	require.Nil(t, o.Report())
+}
@@ -0,0 +1,677 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+const (
+	// serverMaxBodySize is the maximum size in bytes of the body of a request
+	serverMaxBodySize = 10 << 20
+
+	// serverDefaultAliens is the default number of aliens of a simulation created on the server
+	serverDefaultAliens = 5
+
+	// serverDefaultMaxSteps is the default maximum number of steps of a simulation created on the server
+	serverDefaultMaxSteps = 10000
+
+	// serverDefaultStayProbability is the default probability that an alien stays in place with the stay movement strategy
+	serverDefaultStayProbability = 0.5
+
+	// serverStreamKeepAlive is the delay after which a comment is sent on an idle event stream, so that the connection is kept open
+	serverStreamKeepAlive = 15 * time.Second
+)
+
+var (
+	// ErrUnknownSimulation is triggered when an unknown simulation is requested
+	ErrUnknownSimulation error = fmt.Errorf("simulation is unknown")
+
+	// ErrSimulationFinished is triggered when a finished simulation is asked to simulate more steps
+	ErrSimulationFinished error = fmt.Errorf("simulation is already finished")
+
+	// ErrInvalidStepCount is triggered when an invalid number of steps to simulate is provided
+	ErrInvalidStepCount error = fmt.Errorf("step count must be a positive integer")
+
+	// ErrStreamingUnsupported is triggered when a response can't be streamed
+	ErrStreamingUnsupported error = fmt.Errorf("streaming is not supported")
+)
+
+// ServerSimulationRequest is the JSON request that creates a simulation on the server
+// The omitted parameters keep their default value, and the seed defaults to a time based seed
+type ServerSimulationRequest struct {
+	// World map definition
+	Map string `json:"map"`
+
+	// World map format (text, json, yaml), defaults to text
+	MapFormat string `json:"map_format"`
+
+	// Number of aliens that are spawned
+	Aliens uint `json:"aliens"`
+
+	// Maximum number of steps
+	MaxSteps uint `json:"max_steps"`
+
+	// Random generator seed
+	Seed int64 `json:"seed"`
+
+	// Step semantics (sequential, simultaneous)
+	StepMode string `json:"step_mode"`
+
+	// Alien movement strategy (uniform, stay, unvisited, lazy, seek)
+	Movement string `json:"movement"`
+
+	// Probability that an alien stays in place with the stay movement strategy
+	StayProbability float64 `json:"stay_probability"`
+
+	// What is done with geometrically inconsistent links (off, warn, reject, repair)
+	LinkCheck string `json:"link_check"`
+
+	// Are the links two-way roads
+	TwoWay bool `json:"two_way"`
+}
+
+// ServerSimulation is the JSON representation of the state of a simulation on the server
+type ServerSimulation struct {
+	// Simulation identifier
+	ID string `json:"id"`
+
+	// Random generator seed, so that the simulation can be replayed
+	Seed int64 `json:"seed"`
+
+	// Number of steps executed
+	Steps uint `json:"steps"`
+
+	// Is the simulation finished
+	Finished bool `json:"finished"`
+
+	// Reason why the simulation ended
+	TerminationReason string `json:"termination_reason"`
+
+	// Number of cities that are not destroyed
+	AliveCities int `json:"alive_cities"`
+
+	// Number of aliens that are not trapped
+	UntrappedAliens int `json:"untrapped_aliens"`
+
+	// Number of events recorded
+	Events int `json:"events"`
+}
+
+// serverError is the JSON representation of an error returned by the server
+type serverError struct {
+	// Error message
+	Error string `json:"error"`
+}
+
+// Server is an HTTP server exposing a JSON API to create, step, run, inspect and delete simulations
+// Every simulation has its own world, random generator and simulation engine
+//
+//	POST   /simulations                create a simulation from a ServerSimulationRequest
+//	GET    /simulations                list the simulations
+//	GET    /simulations/{id}           retrieve the state of a simulation
+//	DELETE /simulations/{id}           delete a simulation
+//	POST   /simulations/{id}/step      simulate the next steps of a simulation (count query parameter, defaults to 1)
+//	POST   /simulations/{id}/run       run a simulation to completion
+//	GET    /simulations/{id}/world     retrieve the current world of a simulation as a report
+//	GET    /simulations/{id}/events    retrieve the events of a simulation (since query parameter, defaults to 0)
+//	GET    /simulations/{id}/stream    stream the events of a simulation in real time as server-sent events
+type Server struct {
+	// Mutex protecting the simulations
+	mu sync.Mutex
+
+	// Simulations by identifier
+	simulations map[string]*serverSimulation
+
+	// Identifier of the last simulation created
+	lastID uint64
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// NewServer is a server constructor
+func NewServer() *Server {
+	return &Server{
+		simulations: make(map[string]*serverSimulation),
+	}
+}
+
+// ServeHTTP implements http.Handler interface for a server
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(log.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}).Info("ServeHTTP")
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "simulations" || len(parts) > 3 {
+		writeServerError(w, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+		return
+	}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.listSimulations(w, r)
+		case http.MethodPost:
+			s.createSimulation(w, r)
+		default:
+			writeMethodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+
+	simulation, err := s.simulation(parts[1])
+	if err != nil {
+		writeServerError(w, http.StatusNotFound, err)
+		return
+	}
+	action := ""
+	if len(parts) == 3 {
+		action = parts[2]
+	}
+	switch action {
+	case "":
+		switch r.Method {
+		case http.MethodGet:
+			simulation.writeState(w, r, http.StatusOK)
+		case http.MethodDelete:
+			s.deleteSimulation(w, simulation)
+		default:
+			writeMethodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case "step", "run":
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w, http.MethodPost)
+			return
+		}
+		count := uint64(0)
+		if action == "step" {
+			count, err = parseQueryUint(r, "count", 1)
+			if err != nil || count == 0 {
+				writeServerError(w, http.StatusBadRequest, ErrInvalidStepCount)
+				return
+			}
+		}
+		simulation.advance(w, r, count)
+	case "world":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, http.MethodGet)
+			return
+		}
+		simulation.writeWorld(w, r)
+	case "events":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, http.MethodGet)
+			return
+		}
+		since, err := parseQueryUint(r, "since", 0)
+		if err != nil {
+			writeServerError(w, http.StatusBadRequest, err)
+			return
+		}
+		simulation.writeEvents(w, since)
+	case "stream":
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, http.MethodGet)
+			return
+		}
+		since, err := parseStreamSince(r)
+		if err != nil {
+			writeServerError(w, http.StatusBadRequest, err)
+			return
+		}
+		simulation.stream(w, r, since)
+	default:
+		writeServerError(w, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+	}
+}
+
+// listSimulations writes the state of all the simulations, in their creation order
+func (s *Server) listSimulations(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	simulations := make([]*serverSimulation, 0, len(s.simulations))
+	for _, simulation := range s.simulations {
+		simulations = append(simulations, simulation)
+	}
+	s.mu.Unlock()
+	sort.Slice(simulations, func(i, j int) bool {
+		return simulations[i].number < simulations[j].number
+	})
+
+	states := make([]*ServerSimulation, 0, len(simulations))
+	for _, simulation := range simulations {
+		state, err := simulation.state(r.Context())
+		if err != nil {
+			writeServerError(w, http.StatusInternalServerError, err)
+			return
+		}
+		states = append(states, state)
+	}
+	writeServerJSON(w, http.StatusOK, states)
+}
+
+// createSimulation creates and prepares a simulation from the request
+func (s *Server) createSimulation(w http.ResponseWriter, r *http.Request) {
+	request := &ServerSimulationRequest{
+		Aliens:          serverDefaultAliens,
+		MaxSteps:        serverDefaultMaxSteps,
+		Seed:            time.Now().UnixNano(),
+		StayProbability: serverDefaultStayProbability,
+	}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, serverMaxBodySize)).Decode(request)
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	simulation, err := newServerSimulation(r.Context(), request)
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The simulation is numbered once it is successfully created, so that the failed creations do not consume identifiers
+	s.mu.Lock()
+	s.lastID++
+	simulation.number = s.lastID
+	simulation.id = strconv.FormatUint(simulation.number, 10)
+	s.simulations[simulation.id] = simulation
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/simulations/"+simulation.id)
+	simulation.writeState(w, r, http.StatusCreated)
+}
+
+// simulation retrieves a simulation given its identifier
+func (s *Server) simulation(id string) (*serverSimulation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	simulation, found := s.simulations[id]
+	if !found {
+		return nil, ErrUnknownSimulation
+	}
+	return simulation, nil
+}
+
+// deleteSimulation deletes a simulation, unless it has already been deleted by a concurrent request
+func (s *Server) deleteSimulation(w http.ResponseWriter, simulation *serverSimulation) {
+	s.mu.Lock()
+	_, found := s.simulations[simulation.id]
+	delete(s.simulations, simulation.id)
+	s.mu.Unlock()
+	if !found {
+		writeServerError(w, http.StatusNotFound, ErrUnknownSimulation)
+		return
+	}
+	simulation.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serverSimulation is a simulation of the server
+type serverSimulation struct {
+	// Mutex serializing the accesses to the simulation
+	mu sync.Mutex
+
+	// Creation number of the simulation
+	number uint64
+
+	// Simulation identifier
+	id string
+
+	// Random generator seed
+	seed int64
+
+	// World of the simulation
+	world WorldStorer
+
+	// Simulation engine
+	engine *SimulationEngine
+
+	// Report observer, used to describe the world
+	report *ReportObserver
+
+	// Is the simulation finished
+	finished bool
+
+	// Mutex protecting the events, so that they can be streamed while the simulation is running
+	eventsMu sync.Mutex
+
+	// Events recorded
+	events []*JSONEvent
+
+	// Channel closed when new events are recorded, then replaced
+	eventsRecorded chan struct{}
+
+	// Channel closed when the simulation is deleted
+	deleted chan struct{}
+}
+
+var _ Observer = (*serverSimulation)(nil)
+
+// newServerSimulation creates and prepares a simulation given the parameters of a request
+// The simulation is numbered by the server once it is created
+func newServerSimulation(ctx context.Context, request *ServerSimulationRequest) (*serverSimulation, error) {
+	world := NewWorld()
+	engine := NewSimulationEngine(request.Aliens, request.MaxSteps, world, NewRandomSeeded(request.Seed), strings.NewReader(request.Map), nil)
+	mapReader, err := NewMapReader(request.MapFormat, "")
+	if err != nil {
+		return nil, err
+	}
+	engine.SetMapReader(mapReader)
+	engine.SetTwoWayLinks(request.TwoWay)
+	if request.StepMode != "" {
+		stepMode, err := ParseStepMode(request.StepMode)
+		if err != nil {
+			return nil, err
+		}
+		engine.SetStepMode(stepMode)
+	}
+	movementName := request.Movement
+	if movementName == "" {
+		movementName = UniformMovementName
+	}
+	movement, err := NewMovementStrategy(movementName, request.StayProbability)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetMovementStrategy(movement)
+	if request.LinkCheck != "" {
+		linkCheckMode, err := ParseLinkCheckMode(request.LinkCheck)
+		if err != nil {
+			return nil, err
+		}
+		engine.SetLinkCheckMode(linkCheckMode)
+	}
+
+	simulation := &serverSimulation{
+		seed:           request.Seed,
+		world:          world,
+		engine:         engine,
+		report:         NewReportObserver(),
+		events:         make([]*JSONEvent, 0),
+		eventsRecorded: make(chan struct{}),
+		deleted:        make(chan struct{}),
+	}
+	engine.AddObserver(simulation.report)
+	engine.AddObserver(simulation)
+	err = engine.Prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return simulation, nil
+}
+
+// Notify notifies the simulation of one of its events, so that it is recorded
+func (ss *serverSimulation) Notify(ctx context.Context, event *Event) error {
+	ss.eventsMu.Lock()
+	defer ss.eventsMu.Unlock()
+	ss.events = append(ss.events, NewJSONEvent(event))
+
+	// Wake up the streams waiting for new events
+	close(ss.eventsRecorded)
+	ss.eventsRecorded = make(chan struct{})
+	return nil
+}
+
+// close closes the event streams of the simulation once it is deleted
+func (ss *serverSimulation) close() {
+	close(ss.deleted)
+}
+
+// eventsSince retrieves the events recorded since a given index, whether the simulation has finished recording events, and a channel closed when new events are recorded
+func (ss *serverSimulation) eventsSince(since uint64) ([]*JSONEvent, bool, <-chan struct{}) {
+	ss.eventsMu.Lock()
+	defer ss.eventsMu.Unlock()
+	events := make([]*JSONEvent, 0)
+	if since < uint64(len(ss.events)) {
+		events = append(events, ss.events[since:]...)
+	}
+	finished := len(ss.events) > 0 && ss.events[len(ss.events)-1].Type == SimulationFinished.String()
+	return events, finished, ss.eventsRecorded
+}
+
+// advance simulates the next steps of the simulation, until it is finished if count is 0
+// The simulation stays where it is when the request is cancelled
+func (ss *serverSimulation) advance(w http.ResponseWriter, r *http.Request, count uint64) {
+	ctx := r.Context()
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.finished {
+		writeServerError(w, http.StatusConflict, ErrSimulationFinished)
+		return
+	}
+	for step := uint64(0); count == 0 || step < count; step++ {
+		if ctx.Err() != nil {
+			writeServerError(w, http.StatusServiceUnavailable, entity.ErrContextCancelled)
+			return
+		}
+		err := ss.finishIfEndedLocked(ctx)
+		if err != nil {
+			writeServerError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if ss.finished {
+			break
+		}
+		err = ss.engine.SimulateNextStep(ctx)
+		if err != nil {
+			writeServerError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	// The simulation is finished as soon as its last step is simulated, without waiting for another step request
+	if !ss.finished {
+		err := ss.finishIfEndedLocked(ctx)
+		if err != nil {
+			writeServerError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	ss.writeStateLocked(w, r, http.StatusOK)
+}
+
+// finishIfEndedLocked finalizes the simulation if it has no next step, its mutex being held
+func (ss *serverSimulation) finishIfEndedLocked(ctx context.Context) error {
+	hasNextStep, err := ss.engine.HasNextStep(ctx)
+	if err != nil {
+		return err
+	}
+	if hasNextStep {
+		return nil
+	}
+	err = ss.engine.Finalize(ctx)
+	if err != nil {
+		return err
+	}
+	ss.finished = true
+	return nil
+}
+
+// state retrieves the state of the simulation
+func (ss *serverSimulation) state(ctx context.Context) (*ServerSimulation, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.stateLocked(ctx)
+}
+
+// stateLocked retrieves the state of the simulation, its mutex being held
+func (ss *serverSimulation) stateLocked(ctx context.Context) (*ServerSimulation, error) {
+	outcome, err := ss.engine.Outcome(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerSimulation{
+		ID:                ss.id,
+		Seed:              ss.seed,
+		Steps:             outcome.Steps,
+		Finished:          ss.finished,
+		TerminationReason: outcome.Reason.String(),
+		AliveCities:       outcome.AliveCities,
+		UntrappedAliens:   outcome.UntrappedAliens,
+		Events:            ss.totalEvents(),
+	}, nil
+}
+
+// writeState writes the state of the simulation
+func (ss *serverSimulation) writeState(w http.ResponseWriter, r *http.Request, status int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.writeStateLocked(w, r, status)
+}
+
+// writeStateLocked writes the state of the simulation, its mutex being held
+func (ss *serverSimulation) writeStateLocked(w http.ResponseWriter, r *http.Request, status int) {
+	state, err := ss.stateLocked(r.Context())
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServerJSON(w, status, state)
+}
+
+// writeWorld writes the current world of the simulation as a report
+func (ss *serverSimulation) writeWorld(w http.ResponseWriter, r *http.Request) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	outcome, err := ss.engine.Outcome(r.Context())
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+	report, err := ss.report.Snapshot(r.Context(), ss.world, outcome)
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServerJSON(w, http.StatusOK, report)
+}
+
+// totalEvents retrieves the number of events recorded
+func (ss *serverSimulation) totalEvents() int {
+	ss.eventsMu.Lock()
+	defer ss.eventsMu.Unlock()
+	return len(ss.events)
+}
+
+// writeEvents writes the events of the simulation recorded since a given index
+func (ss *serverSimulation) writeEvents(w http.ResponseWriter, since uint64) {
+	events, _, _ := ss.eventsSince(since)
+	writeServerJSON(w, http.StatusOK, events)
+}
+
+// stream streams the events of the simulation recorded since a given index as server-sent events, then the next ones as soon as they are recorded
+// Every event is sent with its index as id and its type as name, and the stream ends once the simulation is finished or deleted
+// A client asking for the events after the end of a finished simulation gets no content, so that it stops reconnecting
+func (ss *serverSimulation) stream(w http.ResponseWriter, r *http.Request, since uint64) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeServerError(w, http.StatusInternalServerError, ErrStreamingUnsupported)
+		return
+	}
+	if events, finished, _ := ss.eventsSince(since); finished && len(events) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(serverStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, finished, eventsRecorded := ss.eventsSince(since)
+		if finished && len(events) == 0 {
+			return
+		}
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.WithError(err).Warn("impossible to encode the event")
+				return
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", since, event.Type, data)
+			if err != nil {
+				return
+			}
+			since++
+			if event.Type == SimulationFinished.String() {
+				flusher.Flush()
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ss.deleted:
+			return
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+		case <-eventsRecorded:
+		}
+	}
+}
+
+// parseQueryUint parses an unsigned integer query parameter, or returns its default value if it is missing
+func parseQueryUint(r *http.Request, name string, defaultValue uint64) (uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s query parameter: %w", name, err)
+	}
+	return parsed, nil
+}
+
+// parseStreamSince parses the index of the first event to stream, from the Last-Event-ID header of a reconnecting client or the since query parameter
+func parseStreamSince(r *http.Request) (uint64, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		return parseQueryUint(r, "since", 0)
+	}
+	parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Last-Event-ID header: %w", err)
+	}
+	return parsed + 1, nil
+}
+
+// writeServerJSON writes a JSON response
+func writeServerJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.WithError(err).Warn("impossible to write the response")
+	}
+}
+
+// writeServerError writes a JSON error response
+func writeServerError(w http.ResponseWriter, status int, err error) {
+	writeServerJSON(w, status, &serverError{Error: err.Error()})
+}
+
+// writeMethodNotAllowed writes a JSON error response for a method that is not allowed
+func writeMethodNotAllowed(w http.ResponseWriter, allowedMethods ...string) {
+	w.Header().Set("Allow", strings.Join(allowedMethods, ", "))
+	writeServerError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+}
@@ -0,0 +1,480 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+const serverTestMap = `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+// serverRequest sends a request to a test server and decodes its JSON response
+func serverRequest(t *testing.T, server *httptest.Server, method, path, body string, response interface{}) int {
+	request, err := http.NewRequestWithContext(context.Background(), method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	res, err := server.Client().Do(request)
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	if response != nil {
+		require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(res.Body).Decode(response))
+	}
+	return res.StatusCode
+}
+
+// createServerSimulation creates a simulation on a test server
+func createServerSimulation(t *testing.T, server *httptest.Server, maxSteps uint) *ServerSimulation {
+	request, err := json.Marshal(&ServerSimulationRequest{
+		Map:             serverTestMap,
+		Aliens:          1,
+		MaxSteps:        maxSteps,
+		Seed:            42,
+		StayProbability: serverDefaultStayProbability,
+	})
+	require.NoError(t, err)
+	simulation := &ServerSimulation{}
+	status := serverRequest(t, server, http.MethodPost, "/simulations", string(request), simulation)
+	require.Equal(t, http.StatusCreated, status)
+	return simulation
+}
+
+func Test_Server_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		giveBody   string
+		wantStatus int
+		wantError  error
+	}{
+		{
+			name:       "Case 1: defaults",
+			giveBody:   `{"map": "City1 north=City2\nCity2 south=City1"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Case 2: JSON map",
+			giveBody:   `{"map": "{\"cities\": [{\"name\": \"City1\"}]}", "map_format": "json", "aliens": 2, "seed": 1, "step_mode": "simultaneous", "movement": "stay", "link_check": "repair", "two_way": true}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Case 3: invalid JSON",
+			giveBody:   `{"map": `,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Case 4: invalid map",
+			giveBody:   `{"map": "City1 north="}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Case 5: unknown movement strategy",
+			giveBody:   `{"map": "City1", "movement": "teleport"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  entity.ErrUnknownMovementStrategy,
+		},
+		{
+			name:       "Case 6: unknown map format",
+			giveBody:   `{"map": "City1", "map_format": "xml"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  entity.ErrUnknownMapFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(NewServer())
+			defer server.Close()
+
+			response := make(map[string]interface{})
+			status := serverRequest(t, server, http.MethodPost, "/simulations", tt.giveBody, &response)
+			require.Equal(t, tt.wantStatus, status)
+			if tt.wantStatus != http.StatusCreated {
+				require.NotEmpty(t, response["error"])
+				if tt.wantError != nil {
+					require.Equal(t, tt.wantError.Error(), response["error"])
+				}
+				return
+			}
+			require.Equal(t, "1", response["id"])
+			require.Equal(t, false, response["finished"])
+			require.Equal(t, "not_terminated", response["termination_reason"])
+		})
+	}
+}
+
+func Test_Server_Lifecycle(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	simulation := createServerSimulation(t, server, 3)
+	require.Equal(t, &ServerSimulation{
+		ID:                "1",
+		Seed:              42,
+		TerminationReason: "not_terminated",
+		AliveCities:       3,
+		UntrappedAliens:   1,
+		Events:            1,
+	}, simulation)
+
+	// Step
+	status := serverRequest(t, server, http.MethodPost, "/simulations/1/step", "", simulation)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint(1), simulation.Steps)
+	require.False(t, simulation.Finished)
+	events := make([]*JSONEvent, 0)
+	status = serverRequest(t, server, http.MethodGet, "/simulations/1/events?since=1", "", &events)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, events, 3)
+	require.Equal(t, "StepStarted", events[0].Type)
+	require.Equal(t, "AlienMoved", events[1].Type)
+	require.Equal(t, "StepEnded", events[2].Type)
+
+	// World
+	report := &Report{}
+	status = serverRequest(t, server, http.MethodGet, "/simulations/1/world", "", report)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint(1), report.Steps)
+	require.Len(t, report.SurvivingCities, 3)
+	require.Len(t, report.UntrappedAliens, 1)
+	require.Equal(t, events[1].To, report.UntrappedAliens[0].City)
+
+	// Run to completion
+	status = serverRequest(t, server, http.MethodPost, "/simulations/1/run", "", simulation)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint(3), simulation.Steps)
+	require.True(t, simulation.Finished)
+	require.Equal(t, "max_steps_reached", simulation.TerminationReason)
+	status = serverRequest(t, server, http.MethodGet, "/simulations/1/events", "", &events)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, simulation.Events, len(events))
+	require.Equal(t, "SimulationFinished", events[len(events)-1].Type)
+
+	// A finished simulation can't simulate more steps
+	response := make(map[string]interface{})
+	status = serverRequest(t, server, http.MethodPost, "/simulations/1/step", "", &response)
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, ErrSimulationFinished.Error(), response["error"])
+
+	// List and delete
+	createServerSimulation(t, server, 0)
+	simulations := make([]*ServerSimulation, 0)
+	status = serverRequest(t, server, http.MethodGet, "/simulations", "", &simulations)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, simulations, 2)
+	require.Equal(t, "1", simulations[0].ID)
+	require.Equal(t, "2", simulations[1].ID)
+	status = serverRequest(t, server, http.MethodDelete, "/simulations/1", "", nil)
+	require.Equal(t, http.StatusNoContent, status)
+	status = serverRequest(t, server, http.MethodGet, "/simulations/1", "", &response)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, ErrUnknownSimulation.Error(), response["error"])
+}
+
+func Test_Server_Identifiers(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	// The failed creations do not consume identifiers
+	for _, wantID := range []string{"1", "2"} {
+		status := serverRequest(t, server, http.MethodPost, "/simulations", `{"map": "City1 north="}`, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+		simulation := createServerSimulation(t, server, 3)
+		require.Equal(t, wantID, simulation.ID)
+	}
+}
+
+func Test_Server_Delete_Concurrent(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	// The simulation is deleted by only one of the concurrent requests, the others do not find it
+	createServerSimulation(t, server, 3)
+	totalRequests := 10
+	statuses := make(chan int, totalRequests)
+	wg := sync.WaitGroup{}
+	for i := 0; i < totalRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, server.URL+"/simulations/1", nil)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			res, err := server.Client().Do(request)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			_ = res.Body.Close()
+			statuses <- res.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	totalStatuses := make(map[int]int)
+	for status := range statuses {
+		totalStatuses[status]++
+	}
+	require.Equal(t, map[int]int{http.StatusNoContent: 1, http.StatusNotFound: totalRequests - 1}, totalStatuses)
+}
+
+func Test_Server_StepCount(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		givePath     string
+		wantStatus   int
+		wantSteps    uint
+		wantFinished bool
+	}{
+		{"Case 1: two steps", "/simulations/1/step?count=2", http.StatusOK, 2, false},
+		{"Case 2: more steps than left", "/simulations/1/step?count=100", http.StatusOK, 5, true},
+		{"Case 3: zero step", "/simulations/2/step?count=0", http.StatusBadRequest, 0, false},
+		{"Case 4: invalid count", "/simulations/2/step?count=many", http.StatusBadRequest, 0, false},
+		{"Case 5: steps left but the last one", "/simulations/2/step?count=4", http.StatusOK, 4, false},
+		{"Case 6: last step", "/simulations/2/step", http.StatusOK, 5, true},
+	}
+
+	createServerSimulation(t, server, 5)
+	createServerSimulation(t, server, 5)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			simulation := &ServerSimulation{}
+			status := serverRequest(t, server, http.MethodPost, tt.givePath, "", simulation)
+			require.Equal(t, tt.wantStatus, status)
+			require.Equal(t, tt.wantSteps, simulation.Steps)
+			require.Equal(t, tt.wantFinished, simulation.Finished)
+		})
+	}
+
+	// The simulation ends at its last step
+	simulation := &ServerSimulation{}
+	status := serverRequest(t, server, http.MethodGet, "/simulations/2", "", simulation)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "max_steps_reached", simulation.TerminationReason)
+	events := make([]*JSONEvent, 0)
+	status = serverRequest(t, server, http.MethodGet, "/simulations/2/events", "", &events)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "SimulationFinished", events[len(events)-1].Type)
+	require.Equal(t, uint(5), events[len(events)-1].Step)
+}
+
+func Test_Server_Routes(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+	createServerSimulation(t, server, 5)
+
+	tests := []struct {
+		name       string
+		giveMethod string
+		givePath   string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"Case 1: unknown route", http.MethodGet, "/cities", http.StatusNotFound, ""},
+		{"Case 2: unknown action", http.MethodGet, "/simulations/1/aliens", http.StatusNotFound, ""},
+		{"Case 3: too deep", http.MethodGet, "/simulations/1/world/cities", http.StatusNotFound, ""},
+		{"Case 4: unknown simulation", http.MethodPost, "/simulations/9/run", http.StatusNotFound, ""},
+		{"Case 5: put simulations", http.MethodPut, "/simulations", http.StatusMethodNotAllowed, "GET, POST"},
+		{"Case 6: post simulation", http.MethodPost, "/simulations/1", http.StatusMethodNotAllowed, "GET, DELETE"},
+		{"Case 7: get step", http.MethodGet, "/simulations/1/step", http.StatusMethodNotAllowed, "POST"},
+		{"Case 8: post world", http.MethodPost, "/simulations/1/world", http.StatusMethodNotAllowed, "GET"},
+		{"Case 9: delete events", http.MethodDelete, "/simulations/1/events", http.StatusMethodNotAllowed, "GET"},
+		{"Case 10: invalid since", http.MethodGet, "/simulations/1/events?since=-1", http.StatusBadRequest, ""},
+		{"Case 11: post stream", http.MethodPost, "/simulations/1/stream", http.StatusMethodNotAllowed, "GET"},
+		{"Case 12: invalid stream since", http.MethodGet, "/simulations/1/stream?since=first", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequestWithContext(context.Background(), tt.giveMethod, server.URL+tt.givePath, nil)
+			require.NoError(t, err)
+			res, err := server.Client().Do(request)
+			require.NoError(t, err)
+			defer func() { _ = res.Body.Close() }()
+			require.Equal(t, tt.wantStatus, res.StatusCode)
+			require.Equal(t, tt.wantAllow, res.Header.Get("Allow"))
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(body), `"error"`)
+		})
+	}
+}
+
+// serverSentEvent is an event read from a server-sent events stream
+type serverSentEvent struct {
+	id, name string
+	event    *JSONEvent
+}
+
+// openServerStream opens the event stream of a simulation on a test server, and reads its events in the background
+// The channel is closed when the stream ends
+func openServerStream(t *testing.T, ctx context.Context, server *httptest.Server, path, lastEventID string) <-chan *serverSentEvent {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := server.Client().Do(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	events := make(chan *serverSentEvent)
+	go func() {
+		defer close(events)
+		defer func() { _ = res.Body.Close() }()
+		scanner := bufio.NewScanner(res.Body)
+		event := &serverSentEvent{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.event = &JSONEvent{}
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event.event) != nil {
+					return
+				}
+			case line == "" && event.event != nil:
+				events <- event
+				event = &serverSentEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func Test_Server_Stream(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+	ctx := context.Background()
+
+	simulation := createServerSimulation(t, server, 3)
+	stream := openServerStream(t, ctx, server, "/simulations/1/stream", "")
+
+	// The spawn is replayed, then the steps are streamed as soon as they are simulated
+	event := <-stream
+	require.Equal(t, &serverSentEvent{id: "0", name: "AlienSpawned", event: event.event}, event)
+	require.Equal(t, uint(0), event.event.Step)
+	status := serverRequest(t, server, http.MethodPost, "/simulations/1/step", "", simulation)
+	require.Equal(t, http.StatusOK, status)
+	for _, wantName := range []string{"StepStarted", "AlienMoved", "StepEnded"} {
+		event = <-stream
+		require.Equal(t, wantName, event.name)
+		require.Equal(t, wantName, event.event.Type)
+		require.Equal(t, uint(1), event.event.Step)
+	}
+
+	// The stream ends once the simulation is finished
+	status = serverRequest(t, server, http.MethodPost, "/simulations/1/run", "", simulation)
+	require.Equal(t, http.StatusOK, status)
+	streamed := 4
+	for event = range stream {
+		require.Equal(t, strconv.Itoa(streamed), event.id)
+		streamed++
+	}
+	require.Equal(t, simulation.Events, streamed)
+	require.Equal(t, "SimulationFinished", event.name)
+	require.Equal(t, "max_steps_reached", event.event.Reason)
+
+	t.Run("Replay", func(t *testing.T) {
+		tests := []struct {
+			name            string
+			givePath        string
+			giveLastEventID string
+			wantFirstID     int
+		}{
+			{"Case 1: all events", "/simulations/1/stream", "", 0},
+			{"Case 2: since", "/simulations/1/stream?since=5", "", 5},
+			{"Case 3: last event id", "/simulations/1/stream?since=1", "6", 7},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ids := make([]string, 0)
+				for event := range openServerStream(t, ctx, server, tt.givePath, tt.giveLastEventID) {
+					ids = append(ids, event.id)
+				}
+				require.Len(t, ids, simulation.Events-tt.wantFirstID)
+				require.Equal(t, strconv.Itoa(tt.wantFirstID), ids[0])
+			})
+		}
+	})
+
+	t.Run("Replay after the end", func(t *testing.T) {
+		tests := []struct {
+			name            string
+			givePath        string
+			giveLastEventID string
+		}{
+			{"Case 1: since", "/simulations/1/stream?since=" + strconv.Itoa(simulation.Events), ""},
+			{"Case 2: since beyond the end", "/simulations/1/stream?since=100", ""},
+			{"Case 3: last event id", "/simulations/1/stream", strconv.Itoa(simulation.Events - 1)},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				requestCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				defer cancel()
+				request, err := http.NewRequestWithContext(requestCtx, http.MethodGet, server.URL+tt.givePath, nil)
+				require.NoError(t, err)
+				if tt.giveLastEventID != "" {
+					request.Header.Set("Last-Event-ID", tt.giveLastEventID)
+				}
+				res, err := server.Client().Do(request)
+				require.NoError(t, err)
+				defer func() { _ = res.Body.Close() }()
+				require.Equal(t, http.StatusNoContent, res.StatusCode)
+			})
+		}
+	})
+}
+
+func Test_Server_Stream_Closed(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+	ctx := context.Background()
+
+	// The stream ends when the simulation is deleted
+	createServerSimulation(t, server, 3)
+	stream := openServerStream(t, ctx, server, "/simulations/1/stream?since=1", "")
+	status := serverRequest(t, server, http.MethodDelete, "/simulations/1", "", nil)
+	require.Equal(t, http.StatusNoContent, status)
+	_, open := <-stream
+	require.False(t, open)
+
+	// The stream ends when the client goes away, and the simulation keeps running
+	createServerSimulation(t, server, 3)
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream = openServerStream(t, streamCtx, server, "/simulations/2/stream", "")
+	<-stream
+	cancel()
+	_, open = <-stream
+	require.False(t, open)
+	simulation := &ServerSimulation{}
+	status = serverRequest(t, server, http.MethodPost, "/simulations/2/run", "", simulation)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, simulation.Finished)
+}
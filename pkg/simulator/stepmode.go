@@ -0,0 +1,40 @@
+package simulator
+
+import (
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+// StepMode represents the semantics used to simulate a step
+type StepMode int
+
+const (
+	// Sequential step mode moves the aliens one by one and resolves a fight as soon as an alien arrives in an occupied city
+	Sequential StepMode = iota
+
+	// Simultaneous step mode lets all the aliens choose their destination from the state at the start of the step, then applies all the moves and resolves the fights together
+	Simultaneous
+)
+
+// String implements Stringer interface for a step mode
+func (sm StepMode) String() string {
+	switch sm {
+	case Sequential:
+		return "sequential"
+	case Simultaneous:
+		return "simultaneous"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseStepMode parses a step mode from its name
+func ParseStepMode(name string) (StepMode, error) {
+	switch name {
+	case Sequential.String():
+		return Sequential, nil
+	case Simultaneous.String():
+		return Simultaneous, nil
+	default:
+		return Sequential, entity.ErrUnknownStepMode
+	}
+}
@@ -0,0 +1,54 @@
+package simulator
+
+// TerminationReason represents the reason why a simulation ended
+type TerminationReason int
+
+const (
+	// NotTerminated means that the simulation has not ended yet
+	NotTerminated TerminationReason = iota
+
+	// MaxStepsReached means that the maximum number of steps was reached
+	MaxStepsReached
+
+	// AllAliensTrapped means that all the aliens were trapped
+	AllAliensTrapped
+
+	// AllCitiesDestroyed means that all the cities were destroyed
+	AllCitiesDestroyed
+
+	// Cancelled means that the simulation was cancelled
+	Cancelled
+)
+
+// String implements Stringer interface for a termination reason
+func (tr TerminationReason) String() string {
+	switch tr {
+	case NotTerminated:
+		return "not_terminated"
+	case MaxStepsReached:
+		return "max_steps_reached"
+	case AllAliensTrapped:
+		return "all_aliens_trapped"
+	case AllCitiesDestroyed:
+		return "all_cities_destroyed"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome represents the outcome of a simulation
+type Outcome struct {
+	// Number of steps executed
+	Steps uint
+
+	// Reason why the simulation ended
+	Reason TerminationReason
+
+	// Number of cities that are not destroyed
+	AliveCities int
+
+	// Number of aliens that are not trapped
+	UntrappedAliens int
+}
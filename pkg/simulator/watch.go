@@ -0,0 +1,256 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+const (
+	// WatchKeyPause is the key that pauses or resumes a watched simulation
+	WatchKeyPause = ' '
+
+	// WatchKeyStep is the key that simulates the next step of a paused simulation
+	WatchKeyStep = 'n'
+
+	// WatchKeyQuit is the key that stops a watched simulation
+	WatchKeyQuit = 'q'
+
+	// watchRecentDestructions is the number of recent destructions displayed
+	watchRecentDestructions = 5
+)
+
+// ANSI escape sequences used to address the terminal
+const (
+	ansiHome       = "\x1b[H"
+	ansiClear      = "\x1b[2J"
+	ansiClearLine  = "\x1b[K"
+	ansiClearBelow = "\x1b[J"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+)
+
+// Watcher runs a simulation step by step and re-renders the world in a terminal after every step
+// It is an observer of the simulation so that it can record the destroyed cities
+type Watcher struct {
+	// Simulator watched
+	simulator Simulator
+
+	// World of the simulation
+	world WorldStorer
+
+	// Delay between two steps
+	delay time.Duration
+
+	// Keys pressed by the user (nil if none)
+	keys <-chan byte
+
+	// Is the simulation paused
+	paused bool
+
+	// ASCII renderer (nil until the simulation is prepared)
+	renderer *ASCIIRenderer
+
+	// Most recent destructions, the last one first
+	recentDestructions []string
+
+	// Output writer
+	out io.Writer
+}
+
+var _ Observer = (*Watcher)(nil)
+
+// NewWatcher is a watcher constructor
+// The watcher must be added as an observer of the simulator
+func NewWatcher(simulator Simulator, world WorldStorer, out io.Writer) *Watcher {
+	return &Watcher{
+		simulator:          simulator,
+		world:              world,
+		delay:              200 * time.Millisecond,
+		recentDestructions: make([]string, 0),
+		out:                out,
+	}
+}
+
+// SetDelay sets the delay between two steps
+func (w *Watcher) SetDelay(delay time.Duration) {
+	w.delay = delay
+}
+
+// SetKeys sets the keys pressed by the user, to pause, step and quit the simulation
+func (w *Watcher) SetKeys(keys <-chan byte) {
+	w.keys = keys
+}
+
+// Notify notifies the observer of a simulation event
+func (w *Watcher) Notify(ctx context.Context, event *Event) error {
+	if event.Type != CityDestroyed {
+		return nil
+	}
+	if w.renderer != nil {
+		w.renderer.AddDestroyedCity(event.City.Name)
+	}
+	destruction := fmt.Sprintf("step %d: %s destroyed by %s", event.Step, event.City.Name, formatAliens(event.Aliens))
+	w.recentDestructions = append([]string{destruction}, w.recentDestructions...)
+	if len(w.recentDestructions) > watchRecentDestructions {
+		w.recentDestructions = w.recentDestructions[:watchRecentDestructions]
+	}
+	return nil
+}
+
+// Run runs the simulation, rendering the world after every step
+// The simulation is cancelled when the quit key is pressed
+func (w *Watcher) Run(ctx context.Context) error {
+	log.Info("Watcher Run")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Prepare, then infer the layout before any city is destroyed
+	err := w.simulator.Prepare(ctx)
+	if err != nil {
+		return err
+	}
+	layout, err := NewLayout(ctx, w.world)
+	if err != nil {
+		return err
+	}
+	w.renderer = NewASCIIRenderer(layout)
+	_, err = fmt.Fprint(w.out, ansiClear+ansiHideCursor)
+	if err != nil {
+		return err
+	}
+	defer func() { _, _ = fmt.Fprint(w.out, ansiShowCursor) }()
+	err = w.render(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Simulate steps
+	for {
+		hasNextStep, err := w.simulator.HasNextStep(ctx)
+		if err != nil {
+			return err
+		}
+		if !hasNextStep {
+			err = w.simulator.Finalize(ctx)
+			if err != nil {
+				return err
+			}
+			return w.render(ctx)
+		}
+		if !w.wait(ctx) {
+			cancel()
+			err = w.simulator.Finalize(ctx)
+			if err != nil {
+				return err
+			}
+			err = w.render(ctx)
+			if err != nil {
+				return err
+			}
+			return entity.ErrContextCancelled
+		}
+		err = w.simulator.SimulateNextStep(ctx)
+		if err != nil {
+			return err
+		}
+		err = w.render(ctx)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// wait waits until the next step must be simulated, handling the keys pressed meanwhile
+// Returns false if the simulation must stop
+func (w *Watcher) wait(ctx context.Context) bool {
+	var tick <-chan time.Time
+	if !w.paused {
+		timer := time.NewTimer(w.delay)
+		defer timer.Stop()
+		tick = timer.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tick:
+			return true
+		case key, ok := <-w.keys:
+			if !ok {
+				// No more keys, the simulation can't stay paused
+				w.keys = nil
+				if w.paused {
+					w.paused = false
+					return true
+				}
+				continue
+			}
+			switch key {
+			case WatchKeyQuit:
+				return false
+			case WatchKeyStep:
+				if w.paused {
+					return true
+				}
+			case WatchKeyPause:
+				w.paused = !w.paused
+				if !w.paused {
+					return true
+				}
+				tick = nil
+				err := w.render(ctx)
+				if err != nil {
+					log.WithError(err).Warn("impossible to render the world")
+				}
+			}
+		}
+	}
+}
+
+// render renders the status of the simulation and the world from the top of the terminal
+func (w *Watcher) render(ctx context.Context) error {
+	outcome, err := w.simulator.Outcome(ctx)
+	if err != nil {
+		return err
+	}
+	status := "running"
+	switch {
+	case outcome.Reason != NotTerminated:
+		status = outcome.Reason.String()
+	case w.paused:
+		status = "paused"
+	}
+	grid := &strings.Builder{}
+	err = w.renderer.Render(ctx, w.world, grid)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{
+		fmt.Sprintf("Step %d - %d remaining aliens - %d alive cities - %s", outcome.Steps, outcome.UntrappedAliens, outcome.AliveCities, status),
+		fmt.Sprintf("[space] pause/resume  [%c] next step  [%c] quit", WatchKeyStep, WatchKeyQuit),
+		"",
+	}
+	lines = append(lines, strings.Split(strings.TrimSuffix(grid.String(), "\n"), "\n")...)
+	lines = append(lines, "", "Recent destructions:")
+	for _, destruction := range w.recentDestructions {
+		lines = append(lines, "  "+destruction)
+	}
+
+	frame := &strings.Builder{}
+	frame.WriteString(ansiHome)
+	for _, line := range lines {
+		frame.WriteString(line + ansiClearLine + "\n")
+	}
+	frame.WriteString(ansiClearBelow)
+	_, err = fmt.Fprint(w.out, frame.String())
+	return err
+}
@@ -0,0 +1,99 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jpraynaud/alien-invasion-simulator/pkg/simulator/entity"
+)
+
+func Test_Watcher_Run(t *testing.T) {
+	input := `
+City1 north=City2 east=City3
+City2 south=City1
+City3 west=City1
+`
+
+	tests := []struct {
+		name        string
+		giveKeys    string
+		giveClose   bool
+		giveDelay   time.Duration
+		wantSteps   uint
+		wantReason  TerminationReason
+		wantError   error
+		wantContain string
+	}{
+		{
+			name:        "Run to completion",
+			giveDelay:   0,
+			wantSteps:   5,
+			wantReason:  MaxStepsReached,
+			wantContain: "Step 5 - 1 remaining aliens - 3 alive cities - max_steps_reached",
+		},
+		{
+			name:        "Pause, step twice and quit",
+			giveKeys:    " nnq",
+			giveDelay:   time.Hour,
+			wantSteps:   2,
+			wantReason:  Cancelled,
+			wantError:   entity.ErrContextCancelled,
+			wantContain: "Step 1 - 1 remaining aliens - 3 alive cities - paused",
+		},
+		{
+			name:        "Pause then resume when the keys are closed",
+			giveKeys:    " ",
+			giveClose:   true,
+			giveDelay:   10 * time.Millisecond,
+			wantSteps:   5,
+			wantReason:  MaxStepsReached,
+			wantContain: "Step 0 - 1 remaining aliens - 3 alive cities - paused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			world := NewWorld()
+			s := NewSimulationEngine(1, 5, world, NewRandomSeeded(42), strings.NewReader(input), nil)
+			out := &bytes.Buffer{}
+			watcher := NewWatcher(s, world, out)
+			s.AddObserver(watcher)
+			watcher.SetDelay(tt.giveDelay)
+			keys := make(chan byte, len(tt.giveKeys))
+			for _, key := range []byte(tt.giveKeys) {
+				keys <- key
+			}
+			if tt.giveClose {
+				close(keys)
+			}
+			watcher.SetKeys(keys)
+
+			err := watcher.Run(ctx)
+			require.Equal(t, tt.wantError, err)
+			outcome, err := s.Outcome(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSteps, outcome.Steps)
+			require.Equal(t, tt.wantReason, outcome.Reason)
+			require.Contains(t, out.String(), tt.wantContain)
+			require.True(t, strings.HasSuffix(out.String(), ansiShowCursor))
+		})
+	}
+}
+
+func Test_Watcher_Notify(t *testing.T) {
+	ctx := context.Background()
+	watcher := NewWatcher(nil, nil, &bytes.Buffer{})
+	aliens := []*entity.Alien{entity.NewAlien(1), entity.NewAlien(2)}
+	for i := 1; i <= watchRecentDestructions+1; i++ {
+		city := entity.NewCity("City" + string(rune('0'+i)))
+		require.NoError(t, watcher.Notify(ctx, &Event{Type: CityDestroyed, Step: uint(i), City: city, Aliens: aliens}))
+	}
+	require.Len(t, watcher.recentDestructions, watchRecentDestructions)
+	require.Equal(t, "step 6: City6 destroyed by Alien #1 and Alien #2", watcher.recentDestructions[0])
+}
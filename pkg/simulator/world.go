@@ -2,6 +2,7 @@ package simulator
 
 import (
 	"context"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 
@@ -13,14 +14,17 @@ type World struct {
 	// Map cities to their names
 	cityMap map[string]*entity.City
 
+	// City names in the order they were added
+	cityNames []string
+
 	// Map aliens to their ids
 	alienMap map[int]*entity.Alien
 
 	// Map trapped aliens to their ids
 	trappedAlienMap map[int]*entity.Alien
 
-	// Map cities to aliens
-	cityAlienMap map[*entity.City]*entity.Alien
+	// Map cities to the aliens in them, in their arrival order
+	cityAlienMap map[*entity.City][]*entity.Alien
 
 	// Map links from cities to other cities
 	linksFromCityMap map[*entity.City][]*entity.City
@@ -34,11 +38,12 @@ func NewWorld() *World {
 		cityMap          = make(map[string]*entity.City)
 		alienMap         = make(map[int]*entity.Alien)
 		trappedAlienMap  = make(map[int]*entity.Alien)
-		cityAlienMap     = make(map[*entity.City]*entity.Alien)
+		cityAlienMap     = make(map[*entity.City][]*entity.Alien)
 		linksFromCityMap = make(map[*entity.City][]*entity.City)
 	)
 	return &World{
 		cityMap:          cityMap,
+		cityNames:        make([]string, 0),
 		alienMap:         alienMap,
 		trappedAlienMap:  trappedAlienMap,
 		cityAlienMap:     cityAlienMap,
@@ -62,12 +67,15 @@ func (w *World) GetCity(ctx context.Context, cityName string) (*entity.City, err
 }
 
 // GetAliveCities retrieves the list of non destroyed cities
+// The cities are returned in the order they were added
 func (w *World) GetAliveCities(ctx context.Context) ([]*entity.City, error) {
 	log.Debug("GetAliveCities")
 
 	var cities []*entity.City
-	for _, city := range w.cityMap {
-		cities = append(cities, city)
+	for _, cityName := range w.cityNames {
+		if city, found := w.cityMap[cityName]; found {
+			cities = append(cities, city)
+		}
 	}
 
 	return cities, nil
@@ -93,6 +101,7 @@ func (w *World) AddCity(ctx context.Context, cityName string) (*entity.City, err
 	// Create a new city and register it
 	newCity := entity.NewCity(cityName)
 	w.cityMap[newCity.Name] = newCity
+	w.cityNames = append(w.cityNames, newCity.Name)
 
 	return newCity, nil
 }
@@ -247,11 +256,11 @@ func (w *World) MoveAlien(ctx context.Context, alien *entity.Alien, city *entity
 	}
 
 	if alien.City != nil {
-		delete(w.cityAlienMap, alien.City)
+		w.removeAlienFromCity(alien, alien.City)
 	}
 
-	alien.City = city
-	w.cityAlienMap[alien.City] = alien
+	alien.MoveTo(city)
+	w.cityAlienMap[alien.City] = append(w.cityAlienMap[alien.City], alien)
 
 	return nil
 }
@@ -293,7 +302,9 @@ func (w *World) TrapAlien(ctx context.Context, alien *entity.Alien) error {
 		return err
 	}
 	if alienFound != nil {
-		delete(w.cityAlienMap, alienFound.City)
+		if alienFound.City != nil {
+			w.removeAlienFromCity(alienFound, alienFound.City)
+		}
 		w.trappedAlienMap[alienFound.AlienID] = alienFound
 		return nil
 	}
@@ -301,33 +312,49 @@ func (w *World) TrapAlien(ctx context.Context, alien *entity.Alien) error {
 	return entity.ErrMissingAlien
 }
 
-// GetAlienAtCity retrieves the alien at a city
-func (w *World) GetAlienAtCity(ctx context.Context, city *entity.City) (*entity.Alien, error) {
+// GetAliensAtCity retrieves the untrapped aliens at a city
+// The aliens are returned in their arrival order
+func (w *World) GetAliensAtCity(ctx context.Context, city *entity.City) ([]*entity.Alien, error) {
 	log.WithFields(log.Fields{
 		"city": city,
-	}).Debug("GetAlienAtCity")
+	}).Debug("GetAliensAtCity")
 
-	var alien *entity.Alien
+	var aliens []*entity.Alien
 	if city == nil {
-		return alien, entity.ErrMissingCity
+		return aliens, entity.ErrMissingCity
 	}
 
 	cityFound, err := w.GetCity(ctx, city.Name)
 	if err != nil {
-		return alien, err
+		return aliens, err
 	}
 	if cityFound == nil {
-		return alien, entity.ErrUnknownCity
+		return aliens, entity.ErrUnknownCity
 	}
 
-	if alienAtCity, found := w.cityAlienMap[city]; found {
-		return alienAtCity, nil
-	}
+	aliens = append(aliens, w.cityAlienMap[city]...)
 
-	return alien, nil
+	return aliens, nil
+}
+
+// removeAlienFromCity removes an alien from the aliens at a city
+func (w *World) removeAlienFromCity(alien *entity.Alien, city *entity.City) {
+	aliensAtCity := w.cityAlienMap[city]
+	for i, alienAtCity := range aliensAtCity {
+		if alienAtCity == alien {
+			aliensAtCity = append(aliensAtCity[:i:i], aliensAtCity[i+1:]...)
+			break
+		}
+	}
+	if len(aliensAtCity) == 0 {
+		delete(w.cityAlienMap, city)
+		return
+	}
+	w.cityAlienMap[city] = aliensAtCity
 }
 
 // GetUntrappedAliens retrieves the list of untrapped aliens
+// The aliens are returned ordered by their ids
 func (w *World) GetUntrappedAliens(ctx context.Context) ([]*entity.Alien, error) {
 	log.Debug("GetUntrappedAliens")
 
@@ -337,6 +364,9 @@ func (w *World) GetUntrappedAliens(ctx context.Context) ([]*entity.Alien, error)
 			aliens = append(aliens, alien)
 		}
 	}
+	sort.Slice(aliens, func(i, j int) bool {
+		return aliens[i].AlienID < aliens[j].AlienID
+	})
 
 	return aliens, nil
 }
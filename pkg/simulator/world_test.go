@@ -78,7 +78,7 @@ func Test_World_CityScenario(t *testing.T) {
 	// CityA and CityB are alive cities
 	aliveCities, err = world.GetAliveCities(ctx)
 	require.NoError(t, err)
-	require.ElementsMatch(t, []*entity.City{cityA, cityB}, aliveCities)
+	require.Equal(t, []*entity.City{cityA, cityB}, aliveCities)
 
 	// CityA is destroyed
 	err = world.DestroyCity(ctx, cityA)
@@ -189,7 +189,7 @@ func Test_World_AlienScenario(t *testing.T) {
 	// Alien1 and Alien2 are untrapped aliens
 	untrappedAliens, err = world.GetUntrappedAliens(ctx)
 	require.NoError(t, err)
-	require.ElementsMatch(t, []*entity.Alien{alien1, alien2}, untrappedAliens)
+	require.Equal(t, []*entity.Alien{alien1, alien2}, untrappedAliens)
 
 	// Alien1 gets trapped
 	err = world.TrapAlien(ctx, alien1)
@@ -249,20 +249,20 @@ func Test_World_CityAlienScenario(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, alien1)
 
-	// Get alien at null city
-	alienFound, err := world.GetAlienAtCity(ctx, cityNull)
+	// Get aliens at null city
+	aliensFound, err := world.GetAliensAtCity(ctx, cityNull)
 	require.ErrorIs(t, err, entity.ErrMissingCity)
-	require.Nil(t, alienFound)
+	require.Nil(t, aliensFound)
 
-	// Get alien at unknown city
-	alienFound, err = world.GetAlienAtCity(ctx, cityZ)
+	// Get aliens at unknown city
+	aliensFound, err = world.GetAliensAtCity(ctx, cityZ)
 	require.ErrorIs(t, err, entity.ErrUnknownCity)
-	require.Nil(t, alienFound)
+	require.Nil(t, aliensFound)
 
-	// Get alien at cityA
-	alienFound, err = world.GetAlienAtCity(ctx, cityA)
+	// Get aliens at cityA
+	aliensFound, err = world.GetAliensAtCity(ctx, cityA)
 	require.NoError(t, err)
-	require.Nil(t, alienFound)
+	require.Nil(t, aliensFound)
 
 	// Move null alien to cityA
 	err = world.MoveAlien(ctx, alienNull, cityA)
@@ -284,24 +284,77 @@ func Test_World_CityAlienScenario(t *testing.T) {
 	err = world.MoveAlien(ctx, alien1, cityA)
 	require.NoError(t, err)
 
-	// Get alien at CityA
-	alienFound, err = world.GetAlienAtCity(ctx, cityA)
+	// Get aliens at CityA
+	aliensFound, err = world.GetAliensAtCity(ctx, cityA)
 	require.NoError(t, err)
-	require.Equal(t, alien1, alienFound)
+	require.Equal(t, []*entity.Alien{alien1}, aliensFound)
 
 	// Move Alien1 to CityB
 	err = world.MoveAlien(ctx, alien1, cityB)
 	require.NoError(t, err)
 
-	// Get alien at CityB
-	alienFound, err = world.GetAlienAtCity(ctx, cityB)
+	// Get aliens at CityB
+	aliensFound, err = world.GetAliensAtCity(ctx, cityB)
 	require.NoError(t, err)
-	require.Equal(t, alien1, alienFound)
+	require.Equal(t, []*entity.Alien{alien1}, aliensFound)
 
-	// Get alien at cityA
-	alienFound, err = world.GetAlienAtCity(ctx, cityA)
+	// Get aliens at cityA
+	aliensFound, err = world.GetAliensAtCity(ctx, cityA)
 	require.NoError(t, err)
-	require.Nil(t, alienFound)
+	require.Nil(t, aliensFound)
+}
+
+func Test_World_SeveralAliensAtCityScenario(t *testing.T) {
+	ctx := context.Background()
+	world := NewWorld()
+
+	// Cities are added
+	cityA, err := world.AddCity(ctx, "CityA")
+	require.NoError(t, err)
+	cityB, err := world.AddCity(ctx, "CityB")
+	require.NoError(t, err)
+
+	// Aliens are added
+	aliens := make([]*entity.Alien, 0)
+	for alienID := 1; alienID <= 3; alienID++ {
+		alien, err := world.AddAlien(ctx, alienID)
+		require.NoError(t, err)
+		aliens = append(aliens, alien)
+	}
+	alien1, alien2, alien3 := aliens[0], aliens[1], aliens[2]
+
+	// Aliens land in CityA in a non ordered way
+	for _, alien := range []*entity.Alien{alien3, alien1, alien2} {
+		err = world.MoveAlien(ctx, alien, cityA)
+		require.NoError(t, err)
+	}
+
+	// Aliens at CityA are returned in their arrival order
+	aliensFound, err := world.GetAliensAtCity(ctx, cityA)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.Alien{alien3, alien1, alien2}, aliensFound)
+
+	// Alien1 leaves CityA for CityB
+	err = world.MoveAlien(ctx, alien1, cityB)
+	require.NoError(t, err)
+	aliensFound, err = world.GetAliensAtCity(ctx, cityA)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.Alien{alien3, alien2}, aliensFound)
+	aliensFound, err = world.GetAliensAtCity(ctx, cityB)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.Alien{alien1}, aliensFound)
+
+	// Trapped aliens are not at CityA anymore
+	err = world.TrapAlien(ctx, alien3)
+	require.NoError(t, err)
+	aliensFound, err = world.GetAliensAtCity(ctx, cityA)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.Alien{alien2}, aliensFound)
+	err = world.TrapAlien(ctx, alien2)
+	require.NoError(t, err)
+	aliensFound, err = world.GetAliensAtCity(ctx, cityA)
+	require.NoError(t, err)
+	require.Nil(t, aliensFound)
 }
 
 func Test_World_LinkScenario(t *testing.T) {
@@ -373,3 +426,41 @@ func Test_World_LinkScenario(t *testing.T) {
 	err = world.AddLink(ctx, cityA, cityC, entity.West)
 	require.NoError(t, err)
 }
+
+func Test_World_OrderingScenario(t *testing.T) {
+	ctx := context.Background()
+	world := NewWorld()
+
+	// Cities are added in a non alphabetical order
+	cityC, err := world.AddCity(ctx, "CityC")
+	require.NoError(t, err)
+	cityA, err := world.AddCity(ctx, "CityA")
+	require.NoError(t, err)
+	cityB, err := world.AddCity(ctx, "CityB")
+	require.NoError(t, err)
+
+	// Alive cities are returned in the order they were added
+	aliveCities, err := world.GetAliveCities(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.City{cityC, cityA, cityB}, aliveCities)
+
+	// Order is kept after a city is destroyed
+	err = world.DestroyCity(ctx, cityA)
+	require.NoError(t, err)
+	aliveCities, err = world.GetAliveCities(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.City{cityC, cityB}, aliveCities)
+
+	// Aliens are added in a non ordered way
+	alien3, err := world.AddAlien(ctx, 3)
+	require.NoError(t, err)
+	alien1, err := world.AddAlien(ctx, 1)
+	require.NoError(t, err)
+	alien2, err := world.AddAlien(ctx, 2)
+	require.NoError(t, err)
+
+	// Untrapped aliens are returned ordered by their ids
+	untrappedAliens, err := world.GetUntrappedAliens(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.Alien{alien1, alien2, alien3}, untrappedAliens)
+}